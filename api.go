@@ -2,39 +2,85 @@ package soundcloudapi
 
 // Track represents the JSON response of a track's info
 type Track struct {
-	Kind              string `json:"kind"`
-	MonetizationModel string `json:"monetization_model"`
-	ID                int64  `json:"id"`
-	Policy            string `json:"polic"`
-	CommentCount      int64  `json:"comment_count"`
-	FullDurationMS    int64  `json:"full_duration"`
-	Downloadable      bool   `json:"downloadable"`
-	HasDownloadsLeft  bool   `json:"has_downloads_left"`
-	CreatedAt         string `json:"created_at"`
-	Description       string `json:"description"`
-	Media             Media  `json:"media"`
-	Title             string `json:"title"`
-	DurationMS        int64  `json:"duration"`
-	ArtworkURL        string `json:"artwork_url"`
-	Public            bool   `json:"public"`
-	Streamable        bool   `json:"streamable"`
-	TagList           string `json:"tag_list"`
-	Genre             string `json:"genre"`
-	RepostsCount      int64  `json:"reposts_count"`
-	LabelName         string `json:"label_name"`
-	LastModified      string `json:"last_modified"`
-	Commentable       bool   `json:"commentable"`
-	URI               string `json:"uri"`
-	DownloadCount     int64  `json:"download_count"`
-	LikesCount        int64  `json:"likes_count"`
-	DisplayDate       string `json:"display_date"`
-	UserID            int64  `json:"user_id"`
-	WaveformURL       string `json:"waveform_url"`
-	Permalink         string `json:"permalink"`
-	PermalinkURL      string `json:"permalink_url"`
-	PlaybackCount     int64  `json:"playback_count"`
-	SecretToken       string `json:"secret_token"`
-	User              User   `json:"user"`
+	Kind              string            `json:"kind"`
+	MonetizationModel MonetizationModel `json:"monetization_model"`
+	ID                int64             `json:"id"`
+	Policy            TrackPolicy       `json:"policy"`
+	CommentCount      int64             `json:"comment_count"`
+	FullDurationMS    int64             `json:"full_duration"`
+	Downloadable      bool              `json:"downloadable"`
+	HasDownloadsLeft  bool              `json:"has_downloads_left"`
+	CreatedAt         string            `json:"created_at"`
+	Description       string            `json:"description"`
+	Media             Media             `json:"media"`
+	Title             string            `json:"title"`
+	DurationMS        int64             `json:"duration"`
+	ArtworkURL        string            `json:"artwork_url"`
+	Public            bool              `json:"public"`
+	Streamable        bool              `json:"streamable"`
+	TagList           string            `json:"tag_list"`
+	Genre             string            `json:"genre"`
+	RepostsCount      int64             `json:"reposts_count"`
+	LabelName         string            `json:"label_name"`
+	LastModified      string            `json:"last_modified"`
+	Commentable       bool              `json:"commentable"`
+	URI               string            `json:"uri"`
+	DownloadCount     int64             `json:"download_count"`
+	LikesCount        int64             `json:"likes_count"`
+	DisplayDate       string            `json:"display_date"`
+	UserID            int64             `json:"user_id"`
+	WaveformURL       string            `json:"waveform_url"`
+	Permalink         string            `json:"permalink"`
+	PermalinkURL      string            `json:"permalink_url"`
+	PlaybackCount     int64             `json:"playback_count"`
+	SecretToken       string            `json:"secret_token"`
+	User              User              `json:"user"`
+}
+
+// TrackPolicy is how SoundCloud lets the client play a track
+type TrackPolicy string
+
+// The policies of a track
+const (
+	PolicyAllow    TrackPolicy = "ALLOW"    // the track can be played
+	PolicyMonetize TrackPolicy = "MONETIZE" // the track can be played with ads
+	PolicySnip     TrackPolicy = "SNIP"     // only a 30 second preview can be played, e.g. Go+ only tracks
+	PolicyBlock    TrackPolicy = "BLOCK"    // the track can't be played, usually in the client's region
+)
+
+// MonetizationModel is how the uploader of a track is paid
+type MonetizationModel string
+
+// The monetization models of a track
+const (
+	MonetizationNotApplicable MonetizationModel = "NOT_APPLICABLE"
+	MonetizationAdSupported   MonetizationModel = "AD_SUPPORTED"
+	MonetizationSubMidTier    MonetizationModel = "SUB_MID_TIER"
+	MonetizationSubHighTier   MonetizationModel = "SUB_HIGH_TIER" // Go+
+	MonetizationBlackbox      MonetizationModel = "BLACKBOX"
+)
+
+// IsBlocked returns true if SoundCloud blocks the track, usually in the client's region
+func (t Track) IsBlocked() bool {
+	return t.Policy == PolicyBlock
+}
+
+// IsSnipped returns true if only a preview of the track can be played
+func (t Track) IsSnipped() bool {
+	return t.Policy == PolicySnip || previewOnly(t)
+}
+
+// IsPlayable returns true if the whole track can be downloaded from one of its transcodings
+func (t Track) IsPlayable() bool {
+	if !t.Streamable || t.IsBlocked() || t.Policy == PolicySnip {
+		return false
+	}
+	for _, transcoding := range t.Media.Transcodings {
+		if !transcoding.Snipped {
+			return true
+		}
+	}
+	return false
 }
 
 // Media contains an array of transcoding for a track
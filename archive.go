@@ -0,0 +1,173 @@
+package soundcloudapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultArchiveMaxAttempts is the default number of times an Archive lets a track fail before skipping it
+const DefaultArchiveMaxAttempts = 3
+
+// ErrTooManyFailures is returned for a track that failed to download too many times according to an Archive
+var ErrTooManyFailures = errors.New("Track failed to download too many times")
+
+// Archive statuses
+const (
+	ArchiveDownloaded = "downloaded"
+	ArchiveFailed     = "failed"
+)
+
+// ArchiveEntry is the record of a transcoding of a track in an Archive
+type ArchiveEntry struct {
+	TrackID  int64     `json:"track_id"`
+	Preset   string    `json:"preset"`
+	MimeType string    `json:"mime_type"`
+	Status   string    `json:"status"`             // ArchiveDownloaded or ArchiveFailed
+	Path     string    `json:"path,omitempty"`     // where the track was downloaded
+	Attempts int       `json:"attempts,omitempty"` // number of failed attempts since the last download
+	Error    string    `json:"error,omitempty"`    // error of the last failed attempt
+	Time     time.Time `json:"time"`
+}
+
+type archiveKey struct {
+	TrackID  int64
+	Preset   string
+	MimeType string
+}
+
+// Archive records which tracks were downloaded, and which failed, in a JSON lines file so that they
+// can be skipped the next time. Tracks are keyed by ID and transcoding preset and mime type, so
+// downloading another transcoding of a track isn't skipped.
+//
+// Every update appends a line to the file, the last line of a track wins. A line cut short by a
+// crash is ignored. An Archive is safe for concurrent use.
+type Archive struct {
+	// MaxAttempts is the number of times a track can fail before ShouldDownload returns false,
+	// defaults to DefaultArchiveMaxAttempts
+	MaxAttempts int
+
+	mu      sync.Mutex
+	file    *os.File
+	entries map[archiveKey]ArchiveEntry
+}
+
+// OpenArchive opens the archive file at path, creating it if it doesn't exist
+func OpenArchive(path string) (*Archive, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "Failed to read archive")
+	}
+
+	a := &Archive{entries: map[archiveKey]ArchiveEntry{}}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		entry := ArchiveEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		a.entries[archiveKey{entry.TrackID, entry.Preset, entry.MimeType}] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "Failed to read archive")
+	}
+
+	a.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to open archive")
+	}
+
+	// Start on a new line if the last one was cut short
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if _, err := a.file.Write([]byte("\n")); err != nil {
+			a.file.Close()
+			return nil, errors.Wrap(err, "Failed to write archive")
+		}
+	}
+
+	return a, nil
+}
+
+// Entry returns the entry of a transcoding of track
+func (a *Archive) Entry(trackID int64, transcoding Transcoding) (ArchiveEntry, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry, ok := a.entries[archiveKey{trackID, transcoding.Preset, transcoding.Format.MimeType}]
+	return entry, ok
+}
+
+// ShouldDownload returns false if the transcoding of the track was downloaded, or failed MaxAttempts times
+func (a *Archive) ShouldDownload(trackID int64, transcoding Transcoding) bool {
+	entry, ok := a.Entry(trackID, transcoding)
+	if !ok {
+		return true
+	}
+	if entry.Status == ArchiveDownloaded {
+		return false
+	}
+
+	maxAttempts := a.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultArchiveMaxAttempts
+	}
+	return entry.Attempts < maxAttempts
+}
+
+// Downloaded records that the transcoding of the track was downloaded to path
+func (a *Archive) Downloaded(trackID int64, transcoding Transcoding, path string) error {
+	return a.update(trackID, transcoding, func(entry *ArchiveEntry) {
+		entry.Status = ArchiveDownloaded
+		entry.Path = path
+		entry.Attempts = 0
+		entry.Error = ""
+	})
+}
+
+// Failed records that downloading the transcoding of the track failed with err
+func (a *Archive) Failed(trackID int64, transcoding Transcoding, err error) error {
+	return a.update(trackID, transcoding, func(entry *ArchiveEntry) {
+		entry.Status = ArchiveFailed
+		entry.Attempts++
+		entry.Error = err.Error()
+	})
+}
+
+// update applies fn to the entry of the transcoding of the track, and appends the entry to the file
+func (a *Archive) update(trackID int64, transcoding Transcoding, fn func(entry *ArchiveEntry)) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := archiveKey{trackID, transcoding.Preset, transcoding.Format.MimeType}
+	entry, ok := a.entries[key]
+	if !ok {
+		entry = ArchiveEntry{TrackID: trackID, Preset: transcoding.Preset, MimeType: transcoding.Format.MimeType}
+	}
+	fn(&entry)
+	entry.Time = time.Now().UTC()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode archive entry")
+	}
+
+	// A single write, so that a crash can only cut the line short
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "Failed to write archive")
+	}
+	a.entries[key] = entry
+	return nil
+}
+
+// Close closes the archive file
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return errors.Wrap(a.file.Close(), "Failed to close archive")
+}
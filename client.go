@@ -2,6 +2,7 @@ package soundcloudapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,26 +11,59 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/grafov/m3u8"
 	"github.com/pkg/errors"
 )
 
+// DefaultHLSWorkers is the number of HLS segments downloaded concurrently by default
+const DefaultHLSWorkers = 8
+
 type client struct {
 	httpClient *http.Client
+	apiBaseURL string
+	retry      RetryPolicy
+
+	// apiLimiter limits requests to api-v2 and mediaLimiter requests to the media CDN, nil doesn't limit requests
+	apiLimiter   *RateLimiter
+	mediaLimiter *RateLimiter
+
+	// hlsWorkers is the number of HLS segments downloaded concurrently and
+	// hlsWindow the number of segments that can be buffered while waiting to be written
+	hlsWorkers int
+	hlsWindow  int
+	hlsVariant VariantPolicy
+
+	clientIDMu sync.RWMutex
 	clientID   string
+
+	// refreshClientID fetches a new client ID when SoundCloud rejects the current one,
+	// nil disables refreshing. refreshMu deduplicates concurrent refreshes.
+	refreshClientID func(ctx context.Context, stale string) (string, error)
+	refreshMu       sync.Mutex
 }
 
 // FailedRequestError is an error response from the SoundCloud API
 type FailedRequestError struct {
-	Status int
-	ErrMsg string
+	Status     int
+	ErrMsg     string
+	RetryAfter time.Duration // the delay requested by the response's Retry-After header, if any
 }
 
-const trackURL = "https://api-v2.soundcloud.com/tracks"
-const resolveURL = "https://api-v2.soundcloud.com/resolve"
-const usersURL = "https://api-v2.soundcloud.com/users/"
-const searchURL = "https://api-v2.soundcloud.com/search"
+// DefaultAPIBaseURL is the base URL of SoundCloud's api-v2
+const DefaultAPIBaseURL = "https://api-v2.soundcloud.com"
+
+// DefaultWebBaseURL is the base URL of the soundcloud.com web app
+const DefaultWebBaseURL = "https://soundcloud.com"
+
+// DefaultAssetBaseURL is the base URL of the JS assets imported by the soundcloud.com web app
+const DefaultAssetBaseURL = "https://a-v2.sndcdn.com/assets/"
+
+const trackPath = "/tracks"
+const resolvePath = "/resolve"
+const usersPath = "/users/"
+const searchPath = "/search"
 
 func (f *FailedRequestError) Error() string {
 	if f.ErrMsg == "" {
@@ -39,17 +73,61 @@ func (f *FailedRequestError) Error() string {
 	return fmt.Sprintf("Request failed with Status %d: %s", f.Status, f.ErrMsg)
 }
 
-func newClient(clientID string, httpClient *http.Client) *client {
+func newClient(clientID string, httpClient *http.Client, apiBaseURL string, retry RetryPolicy) *client {
 	if httpClient == nil {
 		httpClient = http.DefaultClient
 	}
+	if apiBaseURL == "" {
+		apiBaseURL = DefaultAPIBaseURL
+	}
 	return &client{
 		httpClient: httpClient,
 		clientID:   clientID,
+		apiBaseURL: strings.TrimRight(apiBaseURL, "/"),
+		retry:      retry,
+	}
+}
+
+func (c *client) getClientID() string {
+	c.clientIDMu.RLock()
+	defer c.clientIDMu.RUnlock()
+	return c.clientID
+}
+
+func (c *client) setClientID(clientID string) {
+	c.clientIDMu.Lock()
+	defer c.clientIDMu.Unlock()
+	c.clientID = clientID
+}
+
+// renewClientID replaces the stale client ID with a new one. If another caller already
+// replaced it, the current client ID is returned without fetching a new one.
+func (c *client) renewClientID(ctx context.Context, stale string) (string, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	if current := c.getClientID(); current != stale {
+		return current, nil
+	}
+
+	clientID, err := c.refreshClientID(ctx, stale)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to refresh client ID")
 	}
+	c.setClientID(clientID)
+
+	return clientID, nil
 }
 
-func (c *client) makeRequest(method, url string, jsonBody interface{}) ([]byte, error) {
+// isInvalidClientIDError returns true if err is the response to a request made with an invalid client ID
+func isInvalidClientIDError(err error) bool {
+	if failedRequest, ok := err.(*FailedRequestError); ok {
+		return failedRequest.Status == http.StatusUnauthorized || failedRequest.Status == http.StatusForbidden
+	}
+	return false
+}
+
+func (c *client) makeRequest(ctx context.Context, method, u string, jsonBody interface{}) ([]byte, error) {
 	var jsonBytes []byte
 	var err error
 
@@ -60,31 +138,92 @@ func (c *client) makeRequest(method, url string, jsonBody interface{}) ([]byte,
 		}
 	}
 
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(jsonBytes))
+	data, err := c.doRequest(ctx, method, u, jsonBytes)
+	if err == nil || c.refreshClientID == nil || !isInvalidClientIDError(err) {
+		return data, err
+	}
+
+	// The client ID may have been rotated, so get a new one and retry once
+	parsed, parseErr := url.Parse(u)
+	if parseErr != nil {
+		return nil, err
+	}
+	q := parsed.Query()
+	stale := q.Get("client_id")
+	if stale == "" {
+		return nil, err
+	}
+
+	clientID, refreshErr := c.renewClientID(ctx, stale)
+	if refreshErr != nil {
+		return nil, refreshErr
+	}
+	q.Set("client_id", clientID)
+	parsed.RawQuery = q.Encode()
+
+	return c.doRequest(ctx, method, parsed.String(), jsonBytes)
+}
+
+func (c *client) doRequest(ctx context.Context, method, url string, jsonBytes []byte) ([]byte, error) {
+	if method != "GET" {
+		return c.doRequestOnce(ctx, method, url, jsonBytes)
+	}
+
+	var data []byte
+	err := c.withRetry(ctx, func() error {
+		var err error
+		data, err = c.doRequestOnce(ctx, method, url, jsonBytes)
+		return err
+	})
+
+	return data, err
+}
+
+func (c *client) doRequestOnce(ctx context.Context, method, url string, jsonBytes []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(jsonBytes))
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to make http request")
 	}
 
-	res, err := c.httpClient.Do(req)
+	res, err := c.do(req, c.apiLimiter)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode < 200 || res.StatusCode > 299 {
-		if data, err := ioutil.ReadAll(res.Body); err == nil {
-			return nil, &FailedRequestError{Status: res.StatusCode, ErrMsg: string(data)}
-		}
-		return nil, &FailedRequestError{Status: res.StatusCode}
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read response body")
 	}
 
-	data, err := ioutil.ReadAll(res.Body)
+	return data, nil
+}
 
+// do waits for limiter, sends req and returns the response if it has a 2xx status,
+// otherwise a *FailedRequestError is returned
+func (c *client) do(req *http.Request, limiter *RateLimiter) (*http.Response, error) {
+	if err := limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	res, err := c.httpClient.Do(req)
 	if err != nil {
-		return data, nil
+		return nil, err
 	}
 
-	return data, nil
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		failedRequest := &FailedRequestError{
+			Status:     res.StatusCode,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+		}
+		if data, err := ioutil.ReadAll(res.Body); err == nil {
+			failedRequest.ErrMsg = string(data)
+		}
+		return nil, failedRequest
+	}
+
+	return res, nil
 }
 
 func (c *client) buildURL(base string, clientID bool, query ...string) (string, error) {
@@ -103,7 +242,7 @@ func (c *client) buildURL(base string, clientID bool, query ...string) (string,
 	}
 
 	if clientID {
-		q.Add("client_id", c.clientID)
+		q.Set("client_id", c.getClientID())
 	}
 
 	u.RawQuery = q.Encode()
@@ -119,7 +258,7 @@ type GetTrackInfoOptions struct {
 	PlaylistSecretToken string
 }
 
-func (c *client) getTrackInfo(options GetTrackInfoOptions) ([]Track, error) {
+func (c *client) getTrackInfo(ctx context.Context, options GetTrackInfoOptions) ([]Track, error) {
 	var u string
 	var data []byte
 	var err error
@@ -132,15 +271,15 @@ func (c *client) getTrackInfo(options GetTrackInfoOptions) ([]Track, error) {
 		}
 
 		if options.PlaylistID == 0 && options.PlaylistSecretToken == "" {
-			u, err = c.buildURL(trackURL, true, "ids", strings.Join(ids, ","))
+			u, err = c.buildURL(c.apiBaseURL+trackPath, true, "ids", strings.Join(ids, ","))
 		} else {
-			u, err = c.buildURL(trackURL, true, "ids", strings.Join(ids, ","), "playlistId", fmt.Sprintf("%d", options.PlaylistID), "playlistSecretToken", options.PlaylistSecretToken)
+			u, err = c.buildURL(c.apiBaseURL+trackPath, true, "ids", strings.Join(ids, ","), "playlistId", fmt.Sprintf("%d", options.PlaylistID), "playlistSecretToken", options.PlaylistSecretToken)
 		}
 		if err != nil {
 			return nil, errors.Wrap(err, "Failed to build URL for getTrackInfo()")
 		}
 
-		data, err = c.makeRequest("GET", u, nil)
+		data, err = c.makeRequest(ctx, "GET", u, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -152,7 +291,7 @@ func (c *client) getTrackInfo(options GetTrackInfoOptions) ([]Track, error) {
 		}
 	} else if options.URL != "" {
 		// TO-DO: Validate the URL
-		data, err = c.resolve(options.URL)
+		data, err = c.resolve(ctx, options.URL)
 		if err != nil {
 			return nil, err
 		}
@@ -211,7 +350,7 @@ func (c *client) sortTrackInfo(ids []int64, tracks []Track) {
 	}
 }
 
-func (c *client) getMediaURL(url string) (string, error) {
+func (c *client) getMediaURL(ctx context.Context, url string) (string, error) {
 	// The media URL is the actual link to the audio file for the track
 	u, err := c.buildURL(url, true)
 	if err != nil {
@@ -219,7 +358,7 @@ func (c *client) getMediaURL(url string) (string, error) {
 	}
 
 	media := &MediaURLResponse{}
-	data, err := c.makeRequest("GET", u, nil)
+	data, err := c.makeRequest(ctx, "GET", u, nil)
 	if err != nil {
 		return "", err
 	}
@@ -233,14 +372,14 @@ func (c *client) getMediaURL(url string) (string, error) {
 }
 
 // getDownloadURL gets the download URL of a publicly downloadable track
-func (c *client) getDownloadURL(id int64) (string, error) {
-	u, err := c.buildURL(fmt.Sprintf("https://api-v2.soundcloud.com/tracks/%d/download", id), true)
+func (c *client) getDownloadURL(ctx context.Context, id int64) (string, error) {
+	u, err := c.buildURL(fmt.Sprintf("%s%s/%d/download", c.apiBaseURL, trackPath, id), true)
 	if err != nil {
 		return "", errors.Wrap(err, "Failed to build URL for getDownloadURL")
 	}
 
 	res := &DownloadURLResponse{}
-	data, err := c.makeRequest("GET", u, nil)
+	data, err := c.makeRequest(ctx, "GET", u, nil)
 	if err != nil {
 		return "", err
 	}
@@ -253,24 +392,26 @@ func (c *client) getDownloadURL(id int64) (string, error) {
 	return res.URL, nil
 }
 
-func (c *client) downloadProgressive(url string, dst io.Writer) error {
+func (c *client) downloadProgressive(ctx context.Context, url string, dst io.Writer, progress *progressReporter) error {
 	// The track audio file is just a regular audio file that can be downloaded
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return errors.Wrap(err, "Failed to make request")
-	}
+	var res *http.Response
+	err := c.withRetry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return errors.Wrap(err, "Failed to make request")
+		}
 
-	res, err := c.httpClient.Do(req)
+		res, err = c.do(req, c.mediaLimiter)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode < 200 || res.StatusCode > 299 {
-		if data, err := ioutil.ReadAll(res.Body); err == nil {
-			return &FailedRequestError{Status: res.StatusCode, ErrMsg: string(data)}
-		}
-		return &FailedRequestError{Status: res.StatusCode}
+	if progress != nil {
+		progress.setTotal(res.ContentLength, 0, 0)
+		dst = &progressWriter{dst: dst, reporter: progress}
 	}
 
 	_, err = io.Copy(dst, res.Body)
@@ -281,135 +422,193 @@ func (c *client) downloadProgressive(url string, dst io.Writer) error {
 	return nil
 }
 
-func (c *client) downloadHLS(url string, dst io.Writer) error {
+func (c *client) downloadHLS(ctx context.Context, url string, mimeType string, dst io.Writer, progress *progressReporter) error {
 	// The audio for the track is streamed as per the HLS protocol, see: https://en.wikipedia.org/wiki/HTTP_Live_Streaming
-	m3u8Raw, err := c.makeRequest("GET", url, nil)
+	mediaPlaylist, url, err := c.getMediaPlaylist(ctx, url)
 	if err != nil {
 		return err
 	}
 
-	buf := bytes.NewBuffer(m3u8Raw)
-	playlist, listType, err := m3u8.Decode(*buf, true)
+	parts, err := hlsParts(url, mediaPlaylist)
 	if err != nil {
-		return errors.Wrap(err, "Failed to decode m3u8 playlist")
+		return err
 	}
 
-	if mediaPlaylist, ok := playlist.(*m3u8.MediaPlaylist); ok && listType == m3u8.MEDIA {
-		err = c.downloadHLSAll(mediaPlaylist.Segments, dst)
+	// The segments of some containers can't just be concatenated
+	assembler := newAssembler(dst, mimeType)
+	err = c.downloadHLSAll(ctx, parts, assembler, progress)
+	if err != nil {
+		assembler.Discard()
 		return err
 	}
-
-	return errors.New("m3u8 playlist is not a media playlist")
+	return assembler.Close()
 }
 
-func (c *client) downloadHLSAll(segments []*m3u8.MediaSegment, dst io.Writer) error {
-	// Downloads all HLS segments concurrently and stores in memory until
-	// all goroutines are complete, then writes to dst.
-	//
-	// This is okay for small files, but we should create a separate function
-	// that employs some memory efficient algorithm for larger files.
-	downloadedSegments := make([][]byte, len(segments))
-
-	type result struct {
-		Index int
-		Data  []byte
-	}
-
-	count := 0
-
-	for _, segment := range segments {
-		if segment != nil {
-			count++
+func (c *client) downloadHLSAll(ctx context.Context, parts []hlsPart, dst io.Writer, progress *progressReporter) error {
+	// Segments are downloaded concurrently by a fixed number of workers and written to dst in order
+	// as soon as possible. Segments downloaded ahead of the next one to be written are kept in memory,
+	// so at most window segments are dispatched to the workers ahead of the next one to be written.
+	segments := 0
+	var totalDuration time.Duration
+	for _, part := range parts {
+		if !part.Init {
+			segments++
+			totalDuration += part.Duration
 		}
 	}
+	progress.setTotal(-1, segments, totalDuration)
 
-	// Should we use channels here?
-	//
-	// According to: https://github.com/golang/go/wiki/MutexOrChannel,
-	// we are using channels for the right reason. But maybe it's not efficient
-	// to pass each audio segment from goroutine -> channel -> downloadedSegments slice
-	//
-	// Segment size seems to range from ~30kb <---> ~180kb,
-	// if we use sync.Mutex and add the segments directly to the downloadSegments slice would it be
-	// more memory efficient here?
-	resultChan := make(chan *result, count)
-	errChan := make(chan error)
+	workers := c.hlsWorkers
+	if workers <= 0 {
+		workers = DefaultHLSWorkers
+	}
+	window := c.hlsWindow
+	if window <= 0 {
+		window = 2 * workers
+	}
+	if window < workers {
+		window = workers
+	}
 
-	for i, segment := range segments {
+	type job struct {
+		Index int
+		Part  hlsPart
+	}
 
-		if segment == nil {
-			continue
-		}
-		index := i
-		uri := segment.URI
-		go func() {
-			data, err := c.downloadHLSSegment(uri)
-			if err != nil {
-				errChan <- err
+	type result struct {
+		Index int
+		Data  []byte
+		Err   error
+	}
+
+	// Cancelling ctx when we return stops the workers and the requests of any segments still in-flight
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	// A slot is taken when a segment is dispatched and released when it's written to dst.
+	// There are never more than window results pending, so workers never block on results.
+	slots := make(chan struct{}, window)
+	jobs := make(chan job)
+	results := make(chan result, window)
+	keys := newHLSKeyCache(c)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for i, part := range parts {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
 				return
 			}
-			resultChan <- &result{
-				Index: index,
-				Data:  data,
+			select {
+			case jobs <- job{Index: i, Part: part}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				data, err := c.getMediaRange(ctx, j.Part.URI, j.Part.Offset, j.Part.Limit)
+				if err == nil && j.Part.Key != nil {
+					data, err = keys.decrypt(ctx, j.Part.Key, data)
+				}
+				results <- result{Index: j.Index, Data: data, Err: err}
 			}
 		}()
 	}
 
-	complete := 0
-	for {
+	pending := map[int][]byte{}
+	for next := 0; next < len(parts); {
 		select {
-		case err := <-errChan:
-			return err
-		case r := <-resultChan:
-			downloadedSegments[r.Index] = r.Data
-			complete++
+		case r := <-results:
+			if r.Err != nil {
+				return r.Err
+			}
+			pending[r.Index] = r.Data
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 
-		if complete == count {
-			break
+		for data, ok := pending[next]; ok; data, ok = pending[next] {
+			if _, err := dst.Write(data); err != nil {
+				return errors.Wrap(err, "Failed to write HLS segments to dst")
+			}
+			if parts[next].Init {
+				progress.wrote(len(data))
+			} else {
+				progress.wroteSegment(len(data), parts[next].Duration)
+			}
+			delete(pending, next)
+			<-slots
+			next++
 		}
 	}
 
-	for _, data := range downloadedSegments {
-		_, err := dst.Write(data)
+	return nil
+}
+
+// getMedia fetches a file from the media CDN, such as an HLS playlist or segment
+func (c *client) getMedia(ctx context.Context, url string) ([]byte, error) {
+	return c.getMediaRange(ctx, url, 0, 0)
+}
+
+// getMediaRange fetches limit bytes at offset of a file from the media CDN, or the whole file if limit is 0
+func (c *client) getMediaRange(ctx context.Context, url string, offset, limit int64) ([]byte, error) {
+	var data []byte
+	err := c.withRetry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
-			return errors.Wrap(err, "Failed to write HLS segments to dst")
+			return errors.Wrap(err, "Failed to make request")
+		}
+		if limit > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+limit-1))
 		}
-	}
 
-	return nil
-}
+		res, err := c.do(req, c.mediaLimiter)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
 
-func (c *client) downloadHLSSegment(url string) ([]byte, error) {
-	res, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
+		data, err = ioutil.ReadAll(res.Body)
+		if err != nil {
+			return errors.Wrap(err, "Failed to read media data")
+		}
 
-	if res.StatusCode < 200 || res.StatusCode > 299 {
-		if data, err := ioutil.ReadAll(res.Body); err == nil {
-			return nil, &FailedRequestError{Status: res.StatusCode, ErrMsg: string(data)}
+		if limit > 0 && res.StatusCode != http.StatusPartialContent {
+			// The server ignored the range and sent the whole file
+			if int64(len(data)) < offset+limit {
+				return errors.Errorf("Media file is too short for byte range %d@%d", limit, offset)
+			}
+			data = data[offset : offset+limit]
+		}
+		if int64(len(data)) != limit && limit > 0 {
+			return errors.Errorf("Expected %d bytes of media, received %d", limit, len(data))
 		}
-		return nil, &FailedRequestError{Status: res.StatusCode}
-	}
 
-	data, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to read HLS segment data")
-	}
+		return nil
+	})
 
-	return data, nil
+	return data, err
 }
 
-func (c *client) getPlaylistInfo(url string) (Playlist, error) {
+func (c *client) getPlaylistInfo(ctx context.Context, url string) (Playlist, error) {
 	playlist := Playlist{}
-	u, err := c.buildURL(resolveURL, true, "url", url)
+	u, err := c.buildURL(c.apiBaseURL+resolvePath, true, "url", url)
 	if err != nil {
 		return playlist, errors.Wrap(err, "Failed to build URL for getPlaylistInfo")
 	}
 
-	data, err := c.makeRequest("GET", u, nil)
+	data, err := c.makeRequest(ctx, "GET", u, nil)
 	if err != nil {
 		return playlist, err
 	}
@@ -442,23 +641,27 @@ func (c *client) getPlaylistInfo(url string) (Playlist, error) {
 			temp := make([]Track, len(ids))
 			playlist.Tracks = append(playlist.Tracks, temp...)
 
-			workers := len(ids) / 50
+			workers := (len(ids) + 49) / 50
 
 			type result struct {
 				startIndex int
 				trackInfo  []Track
 			}
 
-			errChan := make(chan error)
-			resultsChan := make(chan result)
-			for i := 0; i <= workers; i++ {
+			// Cancelling ctx when we return stops the other workers if one of them fails
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			errChan := make(chan error, workers)
+			resultsChan := make(chan result, workers)
+			for i := 0; i < workers; i++ {
 				start := i * 50
 				end := start + 50
-				if i == workers {
-					end = start + (len(ids) % 50)
+				if end > len(ids) {
+					end = len(ids)
 				}
 				go func() {
-					trackInfo, err := c.getTrackInfo(GetTrackInfoOptions{
+					trackInfo, err := c.getTrackInfo(ctx, GetTrackInfoOptions{
 						ID:                  ids[start:end],
 						PlaylistID:          playlistID,
 						PlaylistSecretToken: playlistSecretToken,
@@ -476,33 +679,23 @@ func (c *client) getPlaylistInfo(url string) (Playlist, error) {
 				}()
 			}
 
-			completeCount := -1
-
-			for {
+			for completeCount := 0; completeCount < workers; {
 				select {
 				case err = <-errChan:
-					if err != nil {
-						return playlist, err
-					}
+					return playlist, err
 				case r := <-resultsChan:
 					completeCount++
 
 					for i, track := range r.trackInfo {
 						playlist.Tracks[r.startIndex+i+5] = track
 					}
-
-					if completeCount == workers {
-						break
-					}
-				}
-
-				if completeCount == workers {
-					break
+				case <-ctx.Done():
+					return playlist, ctx.Err()
 				}
 			}
 
 		} else {
-			trackInfo, err := c.getTrackInfo(GetTrackInfoOptions{
+			trackInfo, err := c.getTrackInfo(ctx, GetTrackInfoOptions{
 				ID:                  ids,
 				PlaylistID:          playlistID,
 				PlaylistSecretToken: playlistSecretToken,
@@ -527,13 +720,13 @@ func (c *client) getPlaylistInfo(url string) (Playlist, error) {
 }
 
 // resolve is a handy API endpoint that returns info from the given resource URL
-func (c *client) resolve(url string) ([]byte, error) {
-	u, err := c.buildURL(resolveURL, true, "url", strings.TrimRight(url, "/"))
+func (c *client) resolve(ctx context.Context, url string) ([]byte, error) {
+	u, err := c.buildURL(c.apiBaseURL+resolvePath, true, "url", strings.TrimRight(url, "/"))
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to build URL for resolve()")
 	}
 
-	data, err := c.makeRequest("GET", u, nil)
+	data, err := c.makeRequest(ctx, "GET", u, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -547,15 +740,15 @@ type GetUserOptions struct {
 	ID         int64
 }
 
-func (c *client) getUser(options GetUserOptions) (User, error) {
+func (c *client) getUser(ctx context.Context, options GetUserOptions) (User, error) {
 	var user User
 	var u string
 	var err error
 
 	if options.ProfileURL != "" {
-		u, err = c.buildURL(resolveURL, true, "url", options.ProfileURL)
+		u, err = c.buildURL(c.apiBaseURL+resolvePath, true, "url", options.ProfileURL)
 	} else if options.ID != 0 {
-		u, err = c.buildURL(usersURL+strconv.FormatInt(options.ID, 10), true)
+		u, err = c.buildURL(c.apiBaseURL+usersPath+strconv.FormatInt(options.ID, 10), true)
 	} else {
 		return user, errors.New("One of options.ProfileURL or options.ID is required")
 	}
@@ -564,7 +757,7 @@ func (c *client) getUser(options GetUserOptions) (User, error) {
 		return user, errors.Wrap(err, "Failed to build URL for getUser()")
 	}
 
-	data, err := c.makeRequest("GET", u, nil)
+	data, err := c.makeRequest(ctx, "GET", u, nil)
 	if err != nil {
 		return user, err
 	}
@@ -587,13 +780,13 @@ type GetLikesOptions struct {
 	Type   string // What type of resource to return. One of ["track", "playlist", "all"]. Defaults to "all"
 }
 
-func (c *client) getLikes(options GetLikesOptions) (*PaginatedQuery, error) {
+func (c *client) getLikes(ctx context.Context, options GetLikesOptions) (*PaginatedQuery, error) {
 	var query PaginatedQuery
 	var u string // URL takes the form: https://api-v2.soundcloud.com/users/<id>/likes
 	var err error
 
 	if options.ProfileURL != "" {
-		user, err := c.getUser(GetUserOptions{ProfileURL: options.ProfileURL})
+		user, err := c.getUser(ctx, GetUserOptions{ProfileURL: options.ProfileURL})
 		if err != nil {
 			return nil, err
 		}
@@ -619,17 +812,22 @@ func (c *client) getLikes(options GetLikesOptions) (*PaginatedQuery, error) {
 		options.Type = "likes"
 	}
 
+	if parsed, err := url.Parse(options.Offset); err == nil && parsed.IsAbs() {
+		// options.Offset is a next_href
+		options.Offset = parsed.Query().Get("offset")
+	}
+
 	if options.Offset == "" {
-		u, err = c.buildURL(usersURL+strconv.FormatInt(options.ID, 10)+"/"+options.Type, true, "limit", strconv.Itoa(options.Limit))
+		u, err = c.buildURL(c.apiBaseURL+usersPath+strconv.FormatInt(options.ID, 10)+"/"+options.Type, true, "limit", strconv.Itoa(options.Limit))
 	} else {
-		u, err = c.buildURL(usersURL+strconv.FormatInt(options.ID, 10)+"/"+options.Type, true, "limit", strconv.Itoa(options.Limit), "offset", options.Offset)
+		u, err = c.buildURL(c.apiBaseURL+usersPath+strconv.FormatInt(options.ID, 10)+"/"+options.Type, true, "limit", strconv.Itoa(options.Limit), "offset", options.Offset)
 	}
 
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to build URL for getLikes()")
 	}
 
-	data, err := c.makeRequest("GET", u, nil)
+	data, err := c.makeRequest(ctx, "GET", u, nil)
 
 	if err != nil {
 		return nil, err
@@ -671,7 +869,7 @@ const KindPlaylist Kind = "playlist"
 // KindUser is the kind for a user
 const KindUser Kind = "users"
 
-func (c *client) search(options SearchOptions) (*PaginatedQuery, error) {
+func (c *client) search(ctx context.Context, options SearchOptions) (*PaginatedQuery, error) {
 	var u string
 	var err error
 
@@ -684,21 +882,25 @@ func (c *client) search(options SearchOptions) (*PaginatedQuery, error) {
 	}
 
 	if options.QueryURL != "" {
-		u = options.QueryURL
+		// next_href doesn't contain the client ID
+		u, err = c.buildURL(options.QueryURL, true)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to build URL for search()")
+		}
 	} else {
 		kind := "/" + options.Kind
 		if kind == "/" {
 			kind = ""
 		}
 
-		u, err = c.buildURL(searchURL+string(kind), true, "q", options.Query, "limit", strconv.Itoa(options.Limit), "offset", strconv.Itoa(options.Offset))
+		u, err = c.buildURL(c.apiBaseURL+searchPath+string(kind), true, "q", options.Query, "limit", strconv.Itoa(options.Limit), "offset", strconv.Itoa(options.Offset))
 
 		if err != nil {
 			return nil, errors.Wrap(err, "Failed to build URL for search()")
 		}
 	}
 
-	data, err := c.makeRequest("GET", u, nil)
+	data, err := c.makeRequest(ctx, "GET", u, nil)
 
 	if err != nil {
 		return nil, err
@@ -713,3 +915,25 @@ func (c *client) search(options SearchOptions) (*PaginatedQuery, error) {
 
 	return response, nil
 }
+
+// getPage fetches the page of a paginated query at nextHref
+func (c *client) getPage(ctx context.Context, nextHref string) (*PaginatedQuery, error) {
+	// next_href doesn't contain the client ID
+	u, err := c.buildURL(nextHref, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to build URL for getPage()")
+	}
+
+	data, err := c.makeRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PaginatedQuery{}
+	err = json.Unmarshal(data, page)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal page")
+	}
+
+	return page, nil
+}
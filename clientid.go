@@ -1,17 +1,72 @@
 package soundcloudapi
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 )
 
+// ErrNoAssetScripts is returned when the web app doesn't import any scripts from the asset base URL
+var ErrNoAssetScripts = errors.New("Could not find any SoundCloud asset scripts")
+
+// ErrClientIDNotFound is returned when none of the web app's asset scripts contain a client ID
+var ErrClientIDNotFound = errors.New("Could not find a SoundCloud client ID")
+
+// InvalidClientIDError is returned when every client ID found in the web app's asset scripts
+// is rejected by the API
+type InvalidClientIDError struct {
+	ClientIDs []string // the rejected client IDs
+}
+
+func (e *InvalidClientIDError) Error() string {
+	return "Every scraped SoundCloud client ID was rejected: " + strings.Join(e.ClientIDs, ", ")
+}
+
+// scriptRegex matches the URL of scripts imported by the web app, which look like this:
+// <script crossorigin src="https://a-v2.sndcdn.com/assets/sdfhkjhsdkf.js"></script>
+var scriptRegex = regexp.MustCompile(`<script[^>]*\ssrc="([^"]+)"`)
+
+// clientIDRegexes match the client ID in the web app's scripts, in order of preference
+var clientIDRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`[,{]client_id:"([a-zA-Z0-9]{8,64})"`),
+	regexp.MustCompile(`\bclient_id\s*[:=]\s*["']([a-zA-Z0-9]{8,64})["']`),
+	regexp.MustCompile(`[?&]client_id=([a-zA-Z0-9]{8,64})\b`),
+}
+
 // FetchClientID fetches a SoundCloud client ID.
 // This algorithm is adapted from:
 //     https://www.npmjs.com/package/soundcloud-key-fetch
 func FetchClientID() (string, error) {
+	return FetchClientIDContext(context.Background())
+}
+
+// FetchClientIDContext is like FetchClientID but with a context
+func FetchClientIDContext(ctx context.Context) (string, error) {
+	return (&ScrapingClientIDProvider{}).ClientID(ctx)
+}
+
+// ScrapingClientIDProvider scrapes a client ID from the soundcloud.com web app, see FetchClientID
+type ScrapingClientIDProvider struct {
+	HTTPClient     *http.Client // the HTTP client to make requests with, defaults to http.DefaultClient
+	WebBaseURL     string       // defaults to DefaultWebBaseURL
+	AssetBaseURL   string       // defaults to DefaultAssetBaseURL
+	APIBaseURL     string       // base URL of the API used to validate client IDs, defaults to DefaultAPIBaseURL
+	SkipValidation bool         // whether or not to return the first client ID found without validating it
+}
+
+// ClientID scrapes a client ID.
+//
+// ErrNoAssetScripts or ErrClientIDNotFound are returned if the web app changed in a way that
+// makes scraping impossible, and an *InvalidClientIDError if every client ID found was rejected.
+// Scripts that fail to load and client IDs that fail to validate, other than with a 401 or 403,
+// are skipped, their errors are only returned when no client ID is left.
+func (p *ScrapingClientIDProvider) ClientID(ctx context.Context) (string, error) {
 	// // // // // // // // // // // // // // // // // // // // // // // // // // // // //
 	// 																					//
 	// The basic notion of how this function works is that SoundCloud provides          //
@@ -25,52 +80,157 @@ func FetchClientID() (string, error) {
 	//																					//
 	// // // // // // // // // // // // // // // // // // // // // // // // // // // // //
 
-	resp, err := http.Get("https://soundcloud.com")
-	if err != nil {
-		return "", errors.Wrap(err, "Failed to fetch SoundCloud Client ID")
+	webBaseURL := p.WebBaseURL
+	if webBaseURL == "" {
+		webBaseURL = DefaultWebBaseURL
+	}
+	assetBaseURL := p.AssetBaseURL
+	if assetBaseURL == "" {
+		assetBaseURL = DefaultAssetBaseURL
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := p.get(ctx, webBaseURL)
 	if err != nil {
-		return "", errors.Wrap(err, "Failed to read body while fetching SoundCloud Client ID")
+		return "", errors.Wrap(err, "Failed to fetch SoundCloud Client ID")
 	}
 
-	bodyString := string(body)
-	// The link to the JS file with the client ID looks like this:
-	// <script crossorigin src="https://a-v2.sndcdn.com/assets/sdfhkjhsdkf.js"></script
-	split := strings.Split(bodyString, `<script crossorigin src="`)
 	urls := []string{}
+	for _, match := range scriptRegex.FindAllStringSubmatch(body, -1) {
+		if strings.HasPrefix(match[1], assetBaseURL) {
+			urls = append(urls, match[1])
+		}
+	}
+
+	if len(urls) == 0 {
+		return "", ErrNoAssetScripts
+	}
 
-	// Extract all the URLS that match our pattern
-	for _, raw := range split {
-		u := strings.Replace(raw, `"></script>`, "", 1)
-		u = strings.Split(u, "\n")[0]
-		if string([]rune(u)[0:31]) == "https://a-v2.sndcdn.com/assets/" {
-			urls = append(urls, u)
+	// Scripts that failed to load are skipped, as long as another one has a client ID
+	scripts, fetchErr := p.getAll(ctx, urls)
+
+	// It seems like the client ID is always in the script imported last,
+	// so the scripts are searched starting from the last one
+	candidates := []string{}
+	seen := map[string]bool{}
+	for i := len(scripts) - 1; i >= 0; i-- {
+		for _, clientID := range extractClientIDs(scripts[i]) {
+			if !seen[clientID] {
+				seen[clientID] = true
+				candidates = append(candidates, clientID)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		if fetchErr != nil {
+			return "", errors.Wrap(fetchErr, "Failed to fetch SoundCloud Client ID")
 		}
+		return "", ErrClientIDNotFound
 	}
 
-	// It seems like our desired URL is always imported last,
-	// so we use urls[len(urls) - 1]
-	resp, err = http.Get(urls[len(urls)-1])
+	if p.SkipValidation {
+		return candidates[0], nil
+	}
+
+	// Only a 401 or 403 rejects a client ID, after any other error the next one is tried
+	var validateErr error
+	for _, clientID := range candidates {
+		valid, err := p.validate(ctx, clientID)
+		if err != nil {
+			validateErr = err
+			continue
+		}
+		if valid {
+			return clientID, nil
+		}
+	}
+
+	if validateErr != nil {
+		return "", errors.Wrap(validateErr, "Failed to validate SoundCloud Client ID")
+	}
+	return "", &InvalidClientIDError{ClientIDs: candidates}
+}
+
+func extractClientIDs(script string) []string {
+	clientIDs := []string{}
+	for _, regex := range clientIDRegexes {
+		for _, match := range regex.FindAllStringSubmatch(script, -1) {
+			clientIDs = append(clientIDs, match[1])
+		}
+	}
+	return clientIDs
+}
+
+// getAll fetches every URL concurrently. The bodies of the URLs that failed are empty,
+// and the first error is returned with the bodies.
+func (p *ScrapingClientIDProvider) getAll(ctx context.Context, urls []string) ([]string, error) {
+	bodies := make([]string, len(urls))
+	errs := make([]error, len(urls))
+
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			bodies[i], errs[i] = p.get(ctx, u)
+		}(i, u)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return bodies, err
+		}
+	}
+
+	return bodies, nil
+}
+
+// validate makes a cheap API request to check whether clientID is accepted.
+// Errors other than a 401 or 403 are returned, since they don't tell whether it is.
+func (p *ScrapingClientIDProvider) validate(ctx context.Context, clientID string) (bool, error) {
+	apiBaseURL := p.APIBaseURL
+	if apiBaseURL == "" {
+		apiBaseURL = DefaultAPIBaseURL
+	}
+
+	q := url.Values{}
+	q.Set("q", "soundcloud")
+	q.Set("limit", "1")
+	q.Set("client_id", clientID)
+	_, err := p.get(ctx, strings.TrimRight(apiBaseURL, "/")+searchPath+"/tracks?"+q.Encode())
+	if isInvalidClientIDError(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (p *ScrapingClientIDProvider) get(ctx context.Context, url string) (string, error) {
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return "", errors.Wrap(err, "Failed to fetch SoundCloud Client ID")
+		return "", errors.Wrap(err, "Failed to make request")
 	}
 
-	body, err = ioutil.ReadAll(resp.Body)
+	res, err := httpClient.Do(req)
 	if err != nil {
-		return "", errors.Wrap(err, "Failed to read body while fetching SoundCloud Client ID")
+		return "", err
 	}
+	defer res.Body.Close()
 
-	bodyString = string(body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", &FailedRequestError{Status: res.StatusCode}
+	}
 
-	// Extract the client ID
-	if strings.Contains(bodyString, `,client_id:"`) {
-		clientID := strings.Split(bodyString, `,client_id:"`)[1]
-		clientID = strings.Split(clientID, `"`)[0]
-		return clientID, nil
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to read body")
 	}
 
-	return "", errors.New("Could not find a SoundCloud client ID")
+	return string(body), nil
 }
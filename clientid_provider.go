@@ -0,0 +1,204 @@
+package soundcloudapi
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultClientIDCacheTTL is how long FileCacheClientIDProvider caches a client ID by default
+const DefaultClientIDCacheTTL = 24 * time.Hour
+
+// ClientIDProvider provides client IDs for the SoundCloud API
+type ClientIDProvider interface {
+	ClientID(ctx context.Context) (string, error)
+}
+
+// ClientIDInvalidator is implemented by ClientIDProviders that cache client IDs. InvalidateClientID is called
+// with a client ID that SoundCloud rejected, so that it isn't provided again.
+type ClientIDInvalidator interface {
+	InvalidateClientID(ctx context.Context, clientID string) error
+}
+
+// StaticClientIDProvider always provides the same client ID
+type StaticClientIDProvider string
+
+// ClientID returns the static client ID
+func (p StaticClientIDProvider) ClientID(ctx context.Context) (string, error) {
+	if p == "" {
+		return "", errors.New("Static client ID is empty")
+	}
+	return string(p), nil
+}
+
+// FileCacheClientIDProvider caches the client IDs provided by Provider in a file, so that
+// processes started within TTL of each other can share one client ID.
+type FileCacheClientIDProvider struct {
+	Provider ClientIDProvider // provides client IDs when the cache is empty or expired
+	Path     string           // path of the cache file, defaults to DefaultClientIDCachePath()
+	TTL      time.Duration    // how long a cached client ID is used for, defaults to DefaultClientIDCacheTTL
+
+	mu sync.Mutex
+}
+
+type clientIDCache struct {
+	ClientID  string    `json:"client_id"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// DefaultClientIDCachePath returns the default path of a FileCacheClientIDProvider's cache file,
+// which is under the user's cache directory
+func DefaultClientIDCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to find user cache directory")
+	}
+	return filepath.Join(dir, "soundcloud-api", "client_id.json"), nil
+}
+
+// ClientID returns the cached client ID, or a client ID from p.Provider if the cache is empty or expired.
+// Problems with the cache, like a path that can't be resolved or a cache that can't be written, don't fail ClientID.
+func (p *FileCacheClientIDProvider) ClientID(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Without a path, for example when there's no home directory, the cache is skipped
+	path, pathErr := p.path()
+
+	ttl := p.TTL
+	if ttl == 0 {
+		ttl = DefaultClientIDCacheTTL
+	}
+
+	if pathErr == nil {
+		if cache, err := readClientIDCache(path); err == nil && cache.ClientID != "" && time.Since(cache.FetchedAt) < ttl {
+			return cache.ClientID, nil
+		}
+	}
+
+	if p.Provider == nil {
+		return "", errors.New("FileCacheClientIDProvider has no Provider")
+	}
+
+	clientID, err := p.Provider.ClientID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// The client ID is still usable if it can't be cached, it's just fetched again next time
+	if pathErr == nil {
+		_ = writeClientIDCache(path, clientIDCache{ClientID: clientID, FetchedAt: time.Now()})
+	}
+
+	return clientID, nil
+}
+
+// InvalidateClientID removes the cached client ID if it is clientID
+func (p *FileCacheClientIDProvider) InvalidateClientID(ctx context.Context, clientID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Without a path nothing was cached
+	if path, err := p.path(); err == nil {
+		if cache, err := readClientIDCache(path); err == nil && cache.ClientID == clientID {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return errors.Wrap(err, "Failed to remove client ID cache")
+			}
+		}
+	}
+
+	if invalidator, ok := p.Provider.(ClientIDInvalidator); ok {
+		return invalidator.InvalidateClientID(ctx, clientID)
+	}
+
+	return nil
+}
+
+func (p *FileCacheClientIDProvider) path() (string, error) {
+	if p.Path != "" {
+		return p.Path, nil
+	}
+	return DefaultClientIDCachePath()
+}
+
+func readClientIDCache(path string) (clientIDCache, error) {
+	cache := clientIDCache{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cache, err
+	}
+	err = json.Unmarshal(data, &cache)
+	return cache, err
+}
+
+// writeClientIDCache atomically replaces the cache file at path
+func writeClientIDCache(path string, cache clientIDCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal client ID cache")
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrap(err, "Failed to create client ID cache directory")
+	}
+
+	tmp, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "Failed to create client ID cache")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "Failed to write client ID cache")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "Failed to write client ID cache")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), path), "Failed to write client ID cache")
+}
+
+// ChainClientIDProvider tries each of its providers in order until one of them provides a client ID
+type ChainClientIDProvider []ClientIDProvider
+
+// ClientID returns the first client ID provided by the chain
+func (p ChainClientIDProvider) ClientID(ctx context.Context) (string, error) {
+	msgs := []string{}
+	for _, provider := range p {
+		clientID, err := provider.ClientID(ctx)
+		if err == nil {
+			return clientID, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		msgs = append(msgs, err.Error())
+	}
+
+	if len(msgs) == 0 {
+		return "", errors.New("ChainClientIDProvider is empty")
+	}
+	return "", errors.Errorf("Every client ID provider failed: %s", strings.Join(msgs, "; "))
+}
+
+// InvalidateClientID invalidates clientID in each provider of the chain that caches client IDs
+func (p ChainClientIDProvider) InvalidateClientID(ctx context.Context, clientID string) error {
+	var firstErr error
+	for _, provider := range p {
+		if invalidator, ok := provider.(ClientIDInvalidator); ok {
+			if err := invalidator.InvalidateClientID(ctx, clientID); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+)
+
+// defaultTrackTemplate is the FilenameTemplate of the tracks downloaded by the download command
+const defaultTrackTemplate = `{{.User.Username}} - {{.Title}}.{{.Ext}}`
+
+// trackResult is the output of a downloaded track
+type trackResult struct {
+	Index   int    `json:"index,omitempty"`
+	URL     string `json:"url,omitempty"`
+	TrackID int64  `json:"track_id,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Preset  string `json:"preset,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (r trackResult) status() string {
+	switch {
+	case r.Error != "":
+		return "failed"
+	case r.Skipped:
+		return "skipped"
+	}
+	return "downloaded"
+}
+
+// printResults prints the results of downloads, and returns an error if any failed
+func (g *globalOptions) printResults(results []trackResult) error {
+	t := &table{header: []string{"#", "STATUS", "TITLE", "PATH"}}
+	failed := 0
+	for i, result := range results {
+		path := result.Path
+		if result.Error != "" {
+			path = result.Error
+			failed++
+		}
+		t.add(i+1, result.status(), result.Title, path)
+	}
+	if err := g.print(results, t); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tracks failed", failed, len(results))
+	}
+	return nil
+}
+
+// openArchive opens the archive at path, or returns nil if path is empty
+func openArchive(path string) (*soundcloudapi.Archive, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return soundcloudapi.OpenArchive(path)
+}
+
+func downloadCommand() *command {
+	var dir, archivePath string
+	return &command{
+		args: "<url>...",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&dir, "dir", ".", "directory to download to")
+			fs.StringVar(&archivePath, "archive", "", "archive file recording downloaded tracks, which are skipped")
+		},
+		run: func(ctx context.Context, g *globalOptions, args []string) error {
+			if len(args) == 0 {
+				return usageError("expected at least one URL")
+			}
+			archive, err := openArchive(archivePath)
+			if err != nil {
+				return err
+			}
+			if archive != nil {
+				defer archive.Close()
+			}
+			sc, err := g.newAPI()
+			if err != nil {
+				return err
+			}
+
+			// The tracks are downloaded like a playlist without an index, the URLs
+			// that aren't tracks are reported as failed. results is in the order of args.
+			results := make([]trackResult, len(args))
+			positions := []int{} // position in args of each track of playlist
+			playlist := soundcloudapi.Playlist{}
+			for i, url := range args {
+				tracks, err := sc.GetTrackInfoContext(ctx, soundcloudapi.GetTrackInfoOptions{URL: url})
+				if err == nil && len(tracks) == 0 {
+					err = fmt.Errorf("no track at %s", url)
+				}
+				if err != nil {
+					results[i] = trackResult{Index: i + 1, URL: url, Error: err.Error()}
+					continue
+				}
+				positions = append(positions, i)
+				playlist.Tracks = append(playlist.Tracks, tracks[0])
+			}
+
+			template := g.template
+			if template == "" {
+				template = defaultTrackTemplate
+			}
+			downloads, err := g.downloadPlaylist(ctx, sc, playlist, dir, soundcloudapi.PlaylistDownloadOptions{
+				Template: template,
+				NoIndex:  true,
+				Archive:  archive,
+			})
+			if err != nil {
+				return err
+			}
+			for j, result := range downloads {
+				i := positions[j]
+				result.Index, result.URL = i+1, args[i]
+				results[i] = result
+			}
+			return g.printResults(results)
+		},
+	}
+}
+
+// downloadPlaylist downloads playlist with the global options, printing progress
+func (g *globalOptions) downloadPlaylist(ctx context.Context, sc *soundcloudapi.API, playlist soundcloudapi.Playlist, dir string, options soundcloudapi.PlaylistDownloadOptions) ([]trackResult, error) {
+	options.Workers = g.concurrency
+	options.Policy = soundcloudapi.DefaultTranscodingPolicy()
+	options.OnResult = func(result soundcloudapi.PlaylistTrackResult) {
+		if g.output == "table" {
+			fmt.Fprintf(g.stderr, "[%d/%d] %s\n", result.Index, len(playlist.Tracks), result.Track.Title)
+		}
+	}
+	playlistResults, err := sc.DownloadPlaylistContext(ctx, playlist, dir, options)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]trackResult, len(playlistResults))
+	for i, result := range playlistResults {
+		results[i] = trackResult{
+			Index:   result.Index,
+			TrackID: result.Track.ID,
+			Title:   result.Track.Title,
+			Preset:  result.Transcoding.Preset,
+			Path:    result.Path,
+			Skipped: result.Skipped,
+			Error:   errorString(result.Err),
+		}
+	}
+	return results, nil
+}
+
+func playlistCommand() *command {
+	var dir, archivePath, index string
+	return &command{
+		args: "<url>",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&dir, "dir", ".", "directory to download to")
+			fs.StringVar(&archivePath, "archive", "", "archive file recording downloaded tracks, which are skipped")
+			fs.StringVar(&index, "index", soundcloudapi.DefaultPlaylistIndex, "name of the M3U8 index written to the directory")
+		},
+		run: func(ctx context.Context, g *globalOptions, args []string) error {
+			if len(args) != 1 {
+				return usageError("expected one URL")
+			}
+			archive, err := openArchive(archivePath)
+			if err != nil {
+				return err
+			}
+			if archive != nil {
+				defer archive.Close()
+			}
+			sc, err := g.newAPI()
+			if err != nil {
+				return err
+			}
+
+			playlist, err := sc.GetPlaylistInfoContext(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			results, err := g.downloadPlaylist(ctx, sc, playlist, dir, soundcloudapi.PlaylistDownloadOptions{
+				Template: g.template,
+				Index:    index,
+				Archive:  archive,
+			})
+			if err != nil {
+				return err
+			}
+			return g.printResults(results)
+		},
+	}
+}
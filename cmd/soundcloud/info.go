@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+)
+
+func infoCommand() *command {
+	return &command{
+		args: "<url>",
+		run: func(ctx context.Context, g *globalOptions, args []string) error {
+			if len(args) != 1 {
+				return usageError("expected one URL")
+			}
+			sc, err := g.newAPI()
+			if err != nil {
+				return err
+			}
+
+			resource, err := sc.ResolveContext(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			t := &table{}
+			switch resource.Kind {
+			case "track":
+				addTrackInfo(t, *resource.Track)
+				return g.print(resource.Track, t)
+			case "playlist":
+				// The resolved playlist only has the first tracks
+				playlist, err := sc.GetPlaylistInfoContext(ctx, resource.Playlist.PermalinkURL)
+				if err != nil {
+					return err
+				}
+				addPlaylistInfo(t, playlist)
+				return g.print(playlist, t)
+			default:
+				addUserInfo(t, *resource.User)
+				return g.print(resource.User, t)
+			}
+		},
+	}
+}
+
+func addTrackInfo(t *table, track soundcloudapi.Track) {
+	transcodings := []string{}
+	for _, transcoding := range track.Media.Transcodings {
+		description := transcoding.Preset + " " + transcoding.Format.Protocol
+		if transcoding.Snipped {
+			description += " (preview)"
+		}
+		transcodings = append(transcodings, description)
+	}
+
+	t.add("Title", track.Title)
+	t.add("Artist", track.User.Username)
+	t.add("ID", track.ID)
+	t.add("URL", track.PermalinkURL)
+	t.add("Duration", formatDuration(track.DurationMS))
+	t.add("Genre", track.Genre)
+	t.add("Created", track.CreatedAt)
+	t.add("Plays", track.PlaybackCount)
+	t.add("Likes", track.LikesCount)
+	t.add("Playable", track.IsPlayable())
+	t.add("Transcodings", strings.Join(transcodings, ", "))
+}
+
+func addPlaylistInfo(t *table, playlist soundcloudapi.Playlist) {
+	kind := "Playlist"
+	if playlist.IsAlbum {
+		kind = "Album"
+	}
+	t.add(kind, playlist.Title)
+	t.add("User", playlist.User.Username)
+	t.add("ID", playlist.ID)
+	t.add("URL", playlist.PermalinkURL)
+	t.add("Duration", formatDuration(playlist.DurationMS))
+	t.add("Tracks", len(playlist.Tracks))
+	for i, track := range playlist.Tracks {
+		t.add(fmt.Sprintf("%d", i+1), fmt.Sprintf("%s - %s (%s)", track.User.Username, track.Title, formatDuration(track.DurationMS)))
+	}
+}
+
+func addUserInfo(t *table, user soundcloudapi.User) {
+	t.add("Username", user.Username)
+	t.add("Name", strings.TrimSpace(user.FirstName+" "+user.LastName))
+	t.add("ID", user.ID)
+	t.add("URL", user.PermalinkURL)
+	t.add("City", user.City)
+	t.add("Followers", user.FollowersCount)
+	t.add("Likes", user.Likes)
+}
+
+func resolveCommand() *command {
+	return &command{
+		args: "<url>",
+		run: func(ctx context.Context, g *globalOptions, args []string) error {
+			if len(args) != 1 {
+				return usageError("expected one URL")
+			}
+			sc, err := g.newAPI()
+			if err != nil {
+				return err
+			}
+
+			resource, err := sc.ResolveContext(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			t := &table{header: []string{"KIND", "ID", "URL"}}
+			t.add(resource.Kind, resource.ID(), resource.PermalinkURL())
+			return g.print(struct {
+				Kind         string `json:"kind"`
+				ID           int64  `json:"id"`
+				PermalinkURL string `json:"permalink_url"`
+			}{resource.Kind, resource.ID(), resource.PermalinkURL()}, t)
+		},
+	}
+}
+
+var searchKinds = map[string]soundcloudapi.Kind{
+	"tracks":    soundcloudapi.KindTrack,
+	"users":     soundcloudapi.KindUser,
+	"albums":    soundcloudapi.KindAlbum,
+	"playlist":  soundcloudapi.KindPlaylist,
+	"playlists": soundcloudapi.KindPlaylist,
+}
+
+func searchCommand() *command {
+	var kind string
+	var limit int
+	return &command{
+		args: "<query>",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&kind, "kind", "tracks", "what to search, tracks, users, albums or playlist")
+			fs.IntVar(&limit, "limit", 10, "maximum number of results")
+		},
+		run: func(ctx context.Context, g *globalOptions, args []string) error {
+			if len(args) == 0 {
+				return usageError("expected a query")
+			}
+			searchKind, ok := searchKinds[kind]
+			if !ok {
+				return usageError(fmt.Sprintf("-kind must be tracks, users, albums or playlist, not %q", kind))
+			}
+			sc, err := g.newAPI()
+			if err != nil {
+				return err
+			}
+
+			pager := sc.SearchPager(soundcloudapi.SearchOptions{Query: strings.Join(args, " "), Kind: searchKind, Limit: limit})
+			page, err := pager.All(ctx, limit)
+			if err != nil {
+				return err
+			}
+
+			switch searchKind {
+			case soundcloudapi.KindTrack:
+				tracks, err := page.GetTracks()
+				if err != nil {
+					return err
+				}
+				t := &table{header: []string{"ID", "ARTIST", "TITLE", "DURATION", "URL"}}
+				for _, track := range tracks {
+					t.add(track.ID, track.User.Username, track.Title, formatDuration(track.DurationMS), track.PermalinkURL)
+				}
+				return g.print(tracks, t)
+			case soundcloudapi.KindUser:
+				users, err := page.GetUsers()
+				if err != nil {
+					return err
+				}
+				t := &table{header: []string{"ID", "USERNAME", "FOLLOWERS", "URL"}}
+				for _, user := range users {
+					t.add(user.ID, user.Username, user.FollowersCount, user.PermalinkURL)
+				}
+				return g.print(users, t)
+			default:
+				playlists, err := page.GetPlaylists()
+				if err != nil {
+					return err
+				}
+				t := &table{header: []string{"ID", "USER", "TITLE", "TRACKS", "URL"}}
+				for _, playlist := range playlists {
+					t.add(playlist.ID, playlist.User.Username, playlist.Title, playlist.TrackCount, playlist.PermalinkURL)
+				}
+				return g.print(playlists, t)
+			}
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+)
+
+// profileURL returns the profile URL of a user given as a URL or a permalink
+func profileURL(profile string) string {
+	if strings.Contains(profile, "://") {
+		return profile
+	}
+	return "https://soundcloud.com/" + strings.TrimPrefix(profile, "/")
+}
+
+func likesCommand() *command {
+	var limit int
+	var syncDir string
+	var trash bool
+	return &command{
+		args: "<profile>",
+		flags: func(fs *flag.FlagSet) {
+			fs.IntVar(&limit, "limit", 50, "maximum number of likes to list, 0 lists every like")
+			fs.StringVar(&syncDir, "sync", "", "directory to download the liked tracks since the last sync to, instead of listing them")
+			fs.BoolVar(&trash, "trash", false, "with -sync, move the tracks that aren't liked anymore to the .trash directory")
+		},
+		run: func(ctx context.Context, g *globalOptions, args []string) error {
+			if len(args) != 1 {
+				return usageError("expected one profile URL or permalink")
+			}
+			sc, err := g.newAPI()
+			if err != nil {
+				return err
+			}
+
+			options := soundcloudapi.GetLikesOptions{ProfileURL: profileURL(args[0]), Limit: 200}
+			if syncDir != "" {
+				return syncLikes(ctx, g, sc, options, syncDir, trash)
+			}
+
+			options.Type = "all"
+			if limit > 0 && limit < options.Limit {
+				options.Limit = limit
+			}
+			page, err := sc.LikesPager(options).All(ctx, limit)
+			if err != nil {
+				return err
+			}
+			likes, err := page.GetLikes()
+			if err != nil {
+				return err
+			}
+
+			t := &table{header: []string{"LIKED", "KIND", "ID", "TITLE", "URL"}}
+			for _, like := range likes {
+				if like.Track.ID != 0 {
+					t.add(like.CreatedAt, "track", like.Track.ID, like.Track.User.Username+" - "+like.Track.Title, like.Track.PermalinkURL)
+				} else {
+					t.add(like.CreatedAt, "playlist", like.Playlist.ID, like.Playlist.Title, like.Playlist.PermalinkURL)
+				}
+			}
+			return g.print(likes, t)
+		},
+	}
+}
+
+func syncLikes(ctx context.Context, g *globalOptions, sc *soundcloudapi.API, likes soundcloudapi.GetLikesOptions, dir string, trash bool) error {
+	summary, err := sc.SyncLikesContext(ctx, likes, dir, soundcloudapi.SyncOptions{
+		Workers:      g.concurrency,
+		Template:     g.template,
+		Policy:       soundcloudapi.DefaultTranscodingPolicy(),
+		TrashUnliked: trash,
+	})
+	if err != nil {
+		return err
+	}
+
+	type syncResult struct {
+		Status  string `json:"status"`
+		TrackID int64  `json:"track_id"`
+		Title   string `json:"title"`
+		Path    string `json:"path,omitempty"`
+		Error   string `json:"error,omitempty"`
+	}
+	results := []syncResult{}
+	for _, track := range summary.Added {
+		results = append(results, syncResult{"added", track.ID, track.Title, track.Path, ""})
+	}
+	for _, track := range summary.Removed {
+		results = append(results, syncResult{"removed", track.ID, track.Title, track.Path, ""})
+	}
+	for _, result := range summary.Skipped {
+		results = append(results, syncResult{"skipped", result.Track.ID, result.Track.Title, result.Path, ""})
+	}
+	for _, result := range summary.Failed {
+		results = append(results, syncResult{"failed", result.Track.ID, result.Track.Title, result.Path, result.Err.Error()})
+	}
+
+	t := &table{header: []string{"STATUS", "TITLE", "PATH"}}
+	for _, result := range results {
+		path := result.Path
+		if result.Error != "" {
+			path = result.Error
+		}
+		t.add(result.Status, result.Title, path)
+	}
+	if err := g.print(results, t); err != nil {
+		return err
+	}
+
+	if len(summary.Failed) > 0 {
+		return fmt.Errorf("%d added, %d removed, %d failed", len(summary.Added), len(summary.Removed), len(summary.Failed))
+	}
+	return nil
+}
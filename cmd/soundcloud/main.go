@@ -0,0 +1,225 @@
+// Command soundcloud looks up and downloads tracks, playlists and likes from SoundCloud.
+//
+// Usage:
+//
+//	soundcloud [global flags] <command> [flags] [arguments]
+//
+// Run "soundcloud help" for the commands and flags.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+)
+
+const usage = `Usage: soundcloud [global flags] <command> [flags] [arguments]
+
+Commands:
+  info <url>                 show a track, playlist or user
+  resolve <url>              show the kind and ID of a URL
+  download <url>...          download tracks
+  playlist <url>             download a playlist
+  likes <profile>            list a user's likes, or sync them to a directory with -sync
+  search <query>             search tracks, users, albums or playlists with -kind
+
+Flags can be given before or after the arguments. Run "soundcloud <command> -h" for the flags of a command.
+
+Global flags:
+`
+
+// command is a subcommand. flags registers its flags on fs, and run is called with the remaining arguments.
+type command struct {
+	flags func(fs *flag.FlagSet)
+	run   func(ctx context.Context, g *globalOptions, args []string) error
+	args  string // usage of the arguments
+}
+
+var commands = map[string]*command{
+	"info":     infoCommand(),
+	"resolve":  resolveCommand(),
+	"download": downloadCommand(),
+	"playlist": playlistCommand(),
+	"likes":    likesCommand(),
+	"search":   searchCommand(),
+}
+
+// globalOptions are the flags every command accepts
+type globalOptions struct {
+	clientID    string
+	proxy       string
+	concurrency int
+	template    string
+	output      string
+	apiBaseURL  string
+
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func newGlobalOptions(stdout io.Writer, stderr io.Writer) *globalOptions {
+	return &globalOptions{
+		stdout:      stdout,
+		stderr:      stderr,
+		clientID:    os.Getenv("SOUNDCLOUD_CLIENT_ID"),
+		concurrency: soundcloudapi.DefaultPlaylistWorkers,
+		output:      "table",
+		apiBaseURL:  soundcloudapi.DefaultAPIBaseURL,
+	}
+}
+
+// register registers the global flags on fs. The current options are the defaults,
+// so that flags given before the command are kept.
+func (g *globalOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.clientID, "client-id", g.clientID, "SoundCloud client ID, scraped and cached if empty (env SOUNDCLOUD_CLIENT_ID)")
+	fs.StringVar(&g.proxy, "proxy", g.proxy, "URL of the proxy to make requests through, defaults to the HTTP_PROXY and HTTPS_PROXY environment variables")
+	fs.IntVar(&g.concurrency, "concurrency", g.concurrency, "number of tracks downloaded at once")
+	fs.StringVar(&g.template, "template", g.template, "FilenameTemplate of downloaded files, e.g. '{{.User.Username}}/{{.Title}}.{{.Ext}}'")
+	fs.StringVar(&g.output, "output", g.output, "output format, table or json")
+	fs.StringVar(&g.apiBaseURL, "api-base-url", g.apiBaseURL, "base URL of api-v2")
+}
+
+// newAPI returns an API configured by the global flags
+func (g *globalOptions) newAPI() (*soundcloudapi.API, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if g.proxy != "" {
+		proxy, err := url.Parse(g.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	httpClient := &http.Client{Transport: transport}
+
+	return soundcloudapi.New(soundcloudapi.APIOptions{
+		ClientID: g.clientID,
+		ClientIDProvider: &soundcloudapi.FileCacheClientIDProvider{
+			Provider: &soundcloudapi.ScrapingClientIDProvider{HTTPClient: httpClient, APIBaseURL: g.apiBaseURL},
+		},
+		HTTPClient:          httpClient,
+		APIBaseURL:          g.apiBaseURL,
+		AutoRefreshClientID: g.clientID == "",
+		RetryPolicy:         soundcloudapi.DefaultRetryPolicy(),
+		StripMobilePrefix:   true,
+		ConvertFirebaseURLs: true,
+	})
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command line and returns the exit code
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	g := newGlobalOptions(stdout, stderr)
+	fs := flag.NewFlagSet("soundcloud", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	g.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	name := fs.Arg(0)
+	if name == "" || name == "help" {
+		fs.SetOutput(stdout)
+		fs.Usage()
+		return 0
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "soundcloud: unknown command %q\n\n", name)
+		fs.Usage()
+		return 2
+	}
+
+	// The global flags are accepted after the command too
+	args = fs.Args()[1:]
+	fs = flag.NewFlagSet("soundcloud "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	g.register(fs)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: soundcloud %s [flags] %s\n\nFlags:\n", name, cmd.args)
+		fs.PrintDefaults()
+	}
+	cmdArgs, err := parseInterspersed(fs, args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+	if g.output != "table" && g.output != "json" {
+		fmt.Fprintf(stderr, "soundcloud: -output must be table or json, not %q\n", g.output)
+		return 2
+	}
+	if g.concurrency < 1 {
+		fmt.Fprintln(stderr, "soundcloud: -concurrency must be at least 1")
+		return 2
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := cmd.run(ctx, g, cmdArgs); err != nil {
+		if _, ok := err.(usageError); ok {
+			fmt.Fprintf(stderr, "soundcloud %s: %v\n\n", name, err)
+			fs.Usage()
+			return 2
+		}
+		fmt.Fprintf(stderr, "soundcloud %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// parseInterspersed parses the flags in args, which can come before, between or after
+// the positional arguments, and returns the positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// A "--" stops flag parsing, so everything after it is positional
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// usageError is returned by commands called with invalid arguments
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
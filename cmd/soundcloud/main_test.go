@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func newCLITestServer() *soundcloudtest.Server {
+	s := soundcloudtest.NewServer()
+	user := soundcloudapi.User{ID: 1, Username: "dj", PermalinkURL: "https://soundcloud.com/dj"}
+	s.AddUser(user)
+
+	tracks := []soundcloudapi.Track{}
+	for i, title := range []string{"Intro", "Outro"} {
+		track := soundcloudapi.Track{
+			ID:           int64(10 + i),
+			Kind:         "track",
+			Title:        title,
+			PermalinkURL: user.PermalinkURL + "/" + strings.ToLower(title),
+			DurationMS:   61000,
+			Streamable:   true,
+			User:         user,
+		}
+		tracks = append(tracks, s.AddTrack(track, soundcloudtest.Audio{Protocol: "progressive", Data: []byte(title)}))
+	}
+	s.AddPlaylist(soundcloudapi.Playlist{ID: 20, Title: "Set", PermalinkURL: user.PermalinkURL + "/sets/set", User: user, Tracks: tracks})
+	s.AddLike(user.ID, soundcloudapi.Like{CreatedAt: "2021-01-01T00:00:00Z", Track: tracks[0]})
+	return s
+}
+
+// runCLI runs the command line against s and returns the exit code and the output
+func runCLI(s *soundcloudtest.Server, args ...string) (int, string, string) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	args = append([]string{"-api-base-url", s.APIBaseURL(), "-client-id", s.ClientID()}, args...)
+	code := run(args, stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCLIResolve(t *testing.T) {
+	s := newCLITestServer()
+	defer s.Close()
+
+	code, stdout, stderr := runCLI(s, "resolve", "https://soundcloud.com/dj/sets/set", "-output", "json")
+	if code != 0 {
+		t.Fatalf("Expected exit code (0), received (%d): %s", code, stderr)
+	}
+	resolved := struct {
+		Kind string `json:"kind"`
+		ID   int64  `json:"id"`
+	}{}
+	if err := json.Unmarshal([]byte(stdout), &resolved); err != nil || resolved.Kind != "playlist" || resolved.ID != 20 {
+		t.Errorf("Expected playlist (20), received (%s) (%v)", stdout, err)
+	}
+
+	code, stdout, _ = runCLI(s, "info", "https://soundcloud.com/dj/intro")
+	if code != 0 || !strings.Contains(stdout, "Intro") || !strings.Contains(stdout, "1:01") {
+		t.Errorf("Expected the info of the track, received (%d) (%s)", code, stdout)
+	}
+}
+
+func TestCLIDownload(t *testing.T) {
+	s := newCLITestServer()
+	defer s.Close()
+
+	dir := t.TempDir()
+	code, stdout, stderr := runCLI(s, "download", "-dir", dir, "https://soundcloud.com/dj/intro", "https://soundcloud.com/dj/outro", "-template", "{{.Title}}.{{.Ext}}")
+	if code != 0 {
+		t.Fatalf("Expected exit code (0), received (%d): %s", code, stderr)
+	}
+	for _, title := range []string{"Intro", "Outro"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, title+".mp3"))
+		if err != nil || string(data) != title {
+			t.Errorf("Expected %s to be downloaded (%v)", title, err)
+		}
+	}
+	if !strings.Contains(stdout, "downloaded") {
+		t.Errorf("Expected the downloads to be listed, received (%s)", stdout)
+	}
+	if _, err := os.Stat(filepath.Join(dir, soundcloudapi.DefaultPlaylistIndex)); !os.IsNotExist(err) {
+		t.Errorf("Expected no index to be written, received (%v)", err)
+	}
+
+	code, _, stderr = runCLI(s, "download", "-dir", dir, "https://soundcloud.com/dj/missing")
+	if code != 1 || !strings.Contains(stderr, "1 of 1 tracks failed") {
+		t.Errorf("Expected a failed download, received (%d) (%s)", code, stderr)
+	}
+
+	// The results are in the order of the URLs, including the ones that aren't tracks
+	code, stdout, _ = runCLI(s, "download", "-dir", dir, "-output", "json", "https://soundcloud.com/dj/intro", "https://soundcloud.com/dj/missing", "https://soundcloud.com/dj/outro")
+	results := []struct {
+		Index int    `json:"index"`
+		URL   string `json:"url"`
+		Title string `json:"title"`
+		Error string `json:"error"`
+	}{}
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil || code != 1 || len(results) != 3 {
+		t.Fatalf("Expected (3) results, received (%d) (%s) (%v)", code, stdout, err)
+	}
+	for i, expected := range []string{"Intro", "", "Outro"} {
+		result := results[i]
+		if result.Index != i+1 || result.Title != expected || (expected == "") != (result.Error != "") {
+			t.Errorf("Expected result %d to be (%s), received (%+v)", i+1, expected, result)
+		}
+	}
+	if results[1].URL != "https://soundcloud.com/dj/missing" {
+		t.Errorf("Expected the missing track's URL, received (%s)", results[1].URL)
+	}
+}
+
+func TestCLIPlaylistAndLikes(t *testing.T) {
+	s := newCLITestServer()
+	defer s.Close()
+
+	dir := t.TempDir()
+	if code, _, stderr := runCLI(s, "-concurrency", "1", "playlist", "-dir", dir, "https://soundcloud.com/dj/sets/set"); code != 0 {
+		t.Fatalf("Expected exit code (0), received (%d): %s", code, stderr)
+	}
+	if _, err := ioutil.ReadFile(filepath.Join(dir, soundcloudapi.DefaultPlaylistIndex)); err != nil {
+		t.Errorf("Expected the playlist index to be written (%v)", err)
+	}
+
+	code, stdout, _ := runCLI(s, "likes", "dj")
+	if code != 0 || !strings.Contains(stdout, "dj - Intro") {
+		t.Errorf("Expected the like to be listed, received (%d) (%s)", code, stdout)
+	}
+
+	code, stdout, stderr := runCLI(s, "likes", "-sync", dir, "dj")
+	if code != 0 || !strings.Contains(stdout, "added") {
+		t.Errorf("Expected the like to be synced, received (%d) (%s) (%s)", code, stdout, stderr)
+	}
+}
+
+func TestCLISearch(t *testing.T) {
+	s := newCLITestServer()
+	defer s.Close()
+
+	code, stdout, _ := runCLI(s, "search", "outro", "--kind", "tracks")
+	if code != 0 || !strings.Contains(stdout, "Outro") || strings.Contains(stdout, "Intro") {
+		t.Errorf("Expected only Outro to be found, received (%d) (%s)", code, stdout)
+	}
+
+	if code, _, _ := runCLI(s, "search", "dj", "-kind", "sounds"); code != 2 {
+		t.Errorf("Expected exit code (2) for an invalid kind, received (%d)", code)
+	}
+}
@@ -0,0 +1,360 @@
+package soundcloudapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// partSuffix is appended to the path of a file being downloaded
+const partSuffix = ".part"
+
+// partMetadata is stored next to a .part file so that the download can be resumed,
+// even by another process, if the file on the server didn't change
+type partMetadata struct {
+	ETag string `json:"etag"`
+	Size int64  `json:"size"` // size of the complete file, -1 if unknown
+}
+
+// DownloadTrackToFile downloads the track specified by the given Transcoding's URL to the file at path.
+//
+// The track is written to path + ".part" and renamed to path when complete. Progressive downloads
+// are resumed from the .part file if a previous download was interrupted, HLS downloads are restarted.
+func (sc *API) DownloadTrackToFile(transcoding Transcoding, path string) error {
+	return sc.DownloadTrackToFileContext(context.Background(), transcoding, path)
+}
+
+// DownloadTrackToFileContext is like DownloadTrackToFile but with a context
+func (sc *API) DownloadTrackToFileContext(ctx context.Context, transcoding Transcoding, path string) error {
+	return sc.DownloadTrackToFileWithOptions(ctx, transcoding, path, DownloadOptions{})
+}
+
+// DownloadTrackToFileWithOptions is like DownloadTrackToFileContext but with DownloadOptions.
+// The progress of a resumed download starts with the bytes of the .part file as written.
+func (sc *API) DownloadTrackToFileWithOptions(ctx context.Context, transcoding Transcoding, path string, options DownloadOptions) error {
+	if err := sc.checkPreview(ctx, transcoding, options.Tag); err != nil {
+		return err
+	}
+	return sc.downloadTrackToFile(ctx, transcoding, path, options)
+}
+
+// downloadTrackToFile downloads transcoding to path without checking whether it's a preview
+func (sc *API) downloadTrackToFile(ctx context.Context, transcoding Transcoding, path string, options DownloadOptions) error {
+	url, err := sc.prepareURL(ctx, transcoding.URL)
+	if err != nil {
+		return err
+	}
+	getURL := func(ctx context.Context) (string, error) {
+		return sc.client.getMediaURL(ctx, url)
+	}
+	var tag *ID3Tag
+	if options.Tag != nil && strings.HasPrefix(transcoding.Format.MimeType, "audio/mpeg") {
+		t, err := sc.client.id3Tag(ctx, *options.Tag)
+		if err != nil {
+			return err
+		}
+		tag = &t
+	}
+
+	progress := newProgressReporter(options.Progress)
+	if strings.Contains(transcoding.URL, "progressive") {
+		return sc.client.downloadToFile(ctx, getURL, path, progress, tag)
+	}
+
+	part, err := os.Create(path + partSuffix)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create file")
+	}
+	defer part.Close()
+
+	var dst io.Writer = part
+	var id3Writer *ID3Writer
+	if tag != nil {
+		id3Writer = NewID3Writer(part, *tag)
+		dst = id3Writer
+	}
+
+	u, err := getURL(ctx)
+	if err == nil {
+		err = sc.client.downloadHLS(ctx, u, transcoding.Format.MimeType, dst, progress)
+	}
+	if err == nil && id3Writer != nil {
+		err = id3Writer.Close()
+	}
+	if err != nil {
+		// HLS downloads are restarted, so the partial file is of no use
+		part.Close()
+		os.Remove(part.Name())
+		return err
+	}
+
+	return completeDownload(part, path)
+}
+
+// DownloadOriginalToFile downloads the original file of the track at url to the file at path.
+// The track must be downloadable. Interrupted downloads are resumed like with DownloadTrackToFile.
+func (sc *API) DownloadOriginalToFile(url string, path string) error {
+	return sc.DownloadOriginalToFileContext(context.Background(), url, path)
+}
+
+// DownloadOriginalToFileContext is like DownloadOriginalToFile but with a context
+func (sc *API) DownloadOriginalToFileContext(ctx context.Context, url string, path string) error {
+	url, err := sc.prepareURL(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	info, err := sc.client.getTrackInfo(ctx, GetTrackInfoOptions{URL: url})
+	if err != nil {
+		return err
+	}
+	if len(info) == 0 {
+		return errors.New("Could not find a track with that URL")
+	}
+	if !info[0].Downloadable || !info[0].HasDownloadsLeft {
+		return errors.New("Track is not downloadable")
+	}
+
+	id := info[0].ID
+	return sc.client.downloadToFile(ctx, func(ctx context.Context) (string, error) {
+		return sc.client.getDownloadURL(ctx, id)
+	}, path, nil, nil)
+}
+
+// downloadToFile downloads the file at the media URL returned by getURL to path, resuming from
+// path's .part file if possible. getURL is called again if the media URL expires between attempts.
+// The .part file only ever has the audio so that it can be resumed, tag is written before it once it's complete.
+func (c *client) downloadToFile(ctx context.Context, getURL func(ctx context.Context) (string, error), path string, progress *progressReporter, tag *ID3Tag) error {
+	partPath := path + partSuffix
+	metadataPath := partPath + ".json"
+
+	part, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "Failed to open file")
+	}
+	defer part.Close()
+
+	// Without metadata there's no way to tell whether the partial file can be resumed
+	metadata, err := readPartMetadata(metadataPath)
+	if err != nil {
+		metadata = partMetadata{Size: -1}
+		if err := part.Truncate(0); err != nil {
+			return errors.Wrap(err, "Failed to truncate file")
+		}
+	}
+
+	// getURL is only attempted once each time, since its attempts are retried with the download's
+	getURLOnce := func() (string, error) {
+		return getURL(withoutRetry(ctx))
+	}
+
+	mediaURL := ""
+	err = c.withRetry(ctx, func() error {
+		fresh := false
+		if mediaURL == "" {
+			u, err := getURLOnce()
+			if err != nil {
+				return err
+			}
+			mediaURL, fresh = u, true
+		}
+
+		err := c.resumeDownload(ctx, mediaURL, part, metadataPath, &metadata, progress)
+		if fresh || !isExpiredMediaURLError(err) {
+			return err
+		}
+
+		// The signed media URL expired since it was fetched
+		mediaURL, err = getURLOnce()
+		if err != nil {
+			mediaURL = ""
+			return err
+		}
+		return c.resumeDownload(ctx, mediaURL, part, metadataPath, &metadata, progress)
+	})
+	if err != nil {
+		return err
+	}
+
+	if tag != nil {
+		err = completeTaggedDownload(part, path, *tag)
+	} else {
+		err = completeDownload(part, path)
+	}
+	if err != nil {
+		return err
+	}
+	os.Remove(metadataPath)
+
+	return nil
+}
+
+// resumeDownload appends the rest of the file at mediaURL to part, or restarts the download
+// if the file on the server doesn't match metadata
+func (c *client) resumeDownload(ctx context.Context, mediaURL string, part *os.File, metadataPath string, metadata *partMetadata, progress *progressReporter) error {
+	offset, err := part.Seek(0, io.SeekEnd)
+	if err != nil {
+		return errors.Wrap(err, "Failed to seek file")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", mediaURL, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to make request")
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if metadata.ETag != "" {
+			req.Header.Set("If-Range", metadata.ETag)
+		}
+	}
+
+	res, err := c.do(req, c.mediaLimiter)
+	if failedRequest, ok := err.(*FailedRequestError); ok && failedRequest.Status == http.StatusRequestedRangeNotSatisfiable {
+		if offset == metadata.Size {
+			progress.restart(offset)
+			progress.setTotal(metadata.Size, 0, 0)
+			return nil
+		}
+		return c.restartDownload(ctx, mediaURL, part, metadataPath, metadata, progress)
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusPartialContent {
+		start, size, ok := parseContentRange(res.Header.Get("Content-Range"))
+		if !ok || start != offset || (metadata.Size >= 0 && size != metadata.Size) {
+			res.Body.Close()
+			return c.restartDownload(ctx, mediaURL, part, metadataPath, metadata, progress)
+		}
+	} else {
+		// The server sent the whole file, because it changed or doesn't support ranges
+		if err := truncatePart(part); err != nil {
+			return err
+		}
+		offset = 0
+		*metadata = partMetadata{ETag: res.Header.Get("ETag"), Size: res.ContentLength}
+		if err := writePartMetadata(metadataPath, *metadata); err != nil {
+			return err
+		}
+	}
+
+	var dst io.Writer = part
+	if progress != nil {
+		progress.restart(offset)
+		progress.setTotal(metadata.Size, 0, 0)
+		dst = &progressWriter{dst: part, reporter: progress}
+	}
+
+	n, err := io.Copy(dst, res.Body)
+	if err != nil {
+		return err
+	}
+	if metadata.Size >= 0 && offset+n != metadata.Size {
+		return io.ErrUnexpectedEOF
+	}
+
+	return nil
+}
+
+func (c *client) restartDownload(ctx context.Context, mediaURL string, part *os.File, metadataPath string, metadata *partMetadata, progress *progressReporter) error {
+	if err := truncatePart(part); err != nil {
+		return err
+	}
+	*metadata = partMetadata{Size: -1}
+	return c.resumeDownload(ctx, mediaURL, part, metadataPath, metadata, progress)
+}
+
+// isExpiredMediaURLError returns true if err is the response of the media CDN to an expired signed URL
+func isExpiredMediaURLError(err error) bool {
+	failedRequest, ok := err.(*FailedRequestError)
+	return ok && failedRequest.Status == http.StatusForbidden
+}
+
+// parseContentRange parses the start and complete size of a Content-Range header like "bytes 100-199/1000"
+func parseContentRange(value string) (start int64, size int64, ok bool) {
+	var end int64
+	if _, err := fmt.Sscanf(value, "bytes %d-%d/%d", &start, &end, &size); err != nil {
+		return 0, 0, false
+	}
+	return start, size, true
+}
+
+func truncatePart(part *os.File) error {
+	if err := part.Truncate(0); err != nil {
+		return errors.Wrap(err, "Failed to truncate file")
+	}
+	_, err := part.Seek(0, io.SeekStart)
+	return errors.Wrap(err, "Failed to seek file")
+}
+
+// completeDownload closes part and renames it to path
+func completeDownload(part *os.File, path string) error {
+	if err := part.Close(); err != nil {
+		return errors.Wrap(err, "Failed to write file")
+	}
+	return errors.Wrap(os.Rename(part.Name(), path), "Failed to rename downloaded file")
+}
+
+// completeTaggedDownload writes tag and the audio of part to path, and removes part
+func completeTaggedDownload(part *os.File, path string, tag ID3Tag) error {
+	if err := part.Close(); err != nil {
+		return errors.Wrap(err, "Failed to write file")
+	}
+	audio, err := os.Open(part.Name())
+	if err != nil {
+		return errors.Wrap(err, "Failed to open file")
+	}
+	defer audio.Close()
+
+	// The tagged file is a .part file of its own, so that path only ever has the complete file
+	tagged, err := os.Create(part.Name() + ".id3")
+	if err != nil {
+		return errors.Wrap(err, "Failed to create file")
+	}
+	defer tagged.Close()
+
+	id3Writer := NewID3Writer(tagged, tag)
+	_, err = io.Copy(id3Writer, audio)
+	if err == nil {
+		err = id3Writer.Close()
+	}
+	if err != nil {
+		tagged.Close()
+		os.Remove(tagged.Name())
+		return errors.Wrap(err, "Failed to write tagged file")
+	}
+	if err := completeDownload(tagged, path); err != nil {
+		return err
+	}
+
+	audio.Close()
+	os.Remove(part.Name())
+	return nil
+}
+
+func readPartMetadata(path string) (partMetadata, error) {
+	metadata := partMetadata{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return metadata, err
+	}
+	err = json.Unmarshal(data, &metadata)
+	return metadata, err
+}
+
+func writePartMetadata(path string, metadata partMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal download metadata")
+	}
+	return errors.Wrap(ioutil.WriteFile(path, data, 0644), "Failed to write download metadata")
+}
@@ -0,0 +1,204 @@
+package soundcloudapi
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// maxComponentBytes is the maximum length of a file or directory name on most file systems
+const maxComponentBytes = 255
+
+// windowsReservedNames can't be the name of a file on Windows, whatever its extension
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// separatorReplacer replaces the path separators in the fields a FilenameTemplate is executed with
+var separatorReplacer = strings.NewReplacer("/", "_", "\\", "_")
+
+// TrackFile is the data a FilenameTemplate is executed with
+type TrackFile struct {
+	Track
+	Index    int      // position of the track in the playlist, starting at 1
+	Playlist Playlist // the playlist the track is downloaded from, if any
+	Ext      string   // extension of the transcoding's mime type without the dot, e.g. "mp3"
+}
+
+// NewTrackFile returns the TrackFile of a track downloaded with transcoding
+func NewTrackFile(track Track, transcoding Transcoding) TrackFile {
+	return TrackFile{Track: track, Ext: mimeExtension(transcoding.Format.MimeType)}
+}
+
+// FilenameTemplate turns tracks into relative file paths that are valid on Windows, macOS and Linux.
+//
+// The template is a text/template executed with a TrackFile, e.g.
+//
+//	{{.User.Username}}/{{.Playlist.Title}}/{{.Index | printf "%02d"}} - {{.Title}}.{{.Ext}}
+//
+// Only the "/" in the template itself separate directories, the ones in the fields are replaced,
+// and empty directory names are dropped, e.g. when a track isn't downloaded from a playlist.
+// Every file and directory name of the path is then sanitized: reserved and control characters are
+// replaced with "_", trailing dots and spaces are removed, Windows reserved names like CON get a "_"
+// appended, and names longer than 255 bytes are shortened while keeping the extension.
+type FilenameTemplate struct {
+	tmpl *template.Template
+}
+
+// NewFilenameTemplate parses text into a FilenameTemplate
+func NewFilenameTemplate(text string) (*FilenameTemplate, error) {
+	tmpl, err := template.New("filename").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse filename template")
+	}
+	return &FilenameTemplate{tmpl: tmpl}, nil
+}
+
+// Execute returns the sanitized path of file, with the separators of the current OS
+func (t *FilenameTemplate) Execute(file TrackFile) (string, error) {
+	sanitizeFields(reflect.ValueOf(&file).Elem())
+
+	buf := &bytes.Buffer{}
+	if err := t.tmpl.Execute(buf, file); err != nil {
+		return "", errors.Wrap(err, "Failed to execute filename template")
+	}
+
+	components := strings.FieldsFunc(buf.String(), func(r rune) bool { return r == '/' || r == '\\' })
+	if len(components) == 0 {
+		return "", errors.Errorf("Filename template returned an empty path for track %d", file.ID)
+	}
+	for i, component := range components {
+		components[i] = sanitizeFilename(component, i == len(components)-1)
+	}
+	return filepath.Join(components...), nil
+}
+
+// sanitizeFields replaces the path separators in every string of v, which must be settable, including
+// in nested structs. Slices aren't modified since they may be shared.
+func sanitizeFields(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(separatorReplacer.Replace(v.String()))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			sanitizeFields(v.Field(i))
+		}
+	}
+}
+
+// sanitizeFilename makes name a valid file or directory name. The extension of the last name of a path is kept when shortening it.
+func sanitizeFilename(name string, last bool) string {
+	name = strings.ToValidUTF8(name, "_")
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimRight(strings.TrimSpace(name), ". ")
+
+	ext := ""
+	if last {
+		ext = filepath.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+	}
+	base := strings.TrimRight(strings.TrimSuffix(name, ext), ". ")
+	if len(base)+len(ext) > maxComponentBytes {
+		base = truncateUTF8(base, maxComponentBytes-len(ext))
+		base = strings.TrimRight(base, ". ")
+	}
+
+	// CON, CON.mp3 and CON.tar.gz are all reserved
+	if windowsReservedNames[strings.ToUpper(strings.SplitN(base, ".", 2)[0])] {
+		first := strings.SplitN(base, ".", 2)
+		first[0] += "_"
+		base = strings.Join(first, ".")
+	}
+
+	if base == "" {
+		base = "_"
+	}
+	return base + ext
+}
+
+// truncateUTF8 returns the longest prefix of s of at most n bytes that doesn't cut a rune
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// DedupePaths returns paths with every path that was already used renamed to "name (2).ext", "name (3).ext"
+// and so on, in order, so that the same paths are always renamed the same way. Paths are compared
+// case-insensitively, like the file systems of Windows and macOS do. The name is truncated to keep
+// the renamed file's name within 255 bytes.
+func DedupePaths(paths []string) []string {
+	key := func(path string) string {
+		return strings.ToLower(filepath.Clean(path))
+	}
+	original := map[string]bool{}
+	for _, path := range paths {
+		original[key(path)] = true
+	}
+
+	used := map[string]bool{}
+	deduped := make([]string, len(paths))
+	for i, path := range paths {
+		candidate := path
+		ext := filepath.Ext(path)
+		stem := strings.TrimSuffix(path, ext)
+		// Only the file name is truncated, not the directories
+		dir := stem[:strings.LastIndexAny(stem, "/"+string(filepath.Separator))+1]
+		for n := 2; used[key(candidate)] || (candidate != path && original[key(candidate)]); n++ {
+			suffix := fmt.Sprintf(" (%d)%s", n, ext)
+			candidate = dir + truncateUTF8(stem[len(dir):], maxComponentBytes-len(suffix)) + suffix
+		}
+		used[key(candidate)] = true
+		deduped[i] = candidate
+	}
+	return deduped
+}
+
+// mimeExtension returns the file extension of mimeType without the dot
+func mimeExtension(mimeType string) string {
+	mediaType, params, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return "audio"
+	}
+
+	switch mediaType {
+	case "audio/mpeg":
+		return "mp3"
+	case "audio/mp4":
+		return "m4a"
+	case "audio/ogg":
+		if strings.Contains(params["codecs"], "opus") {
+			return "opus"
+		}
+		return "ogg"
+	case "audio/wav", "audio/x-wav":
+		return "wav"
+	case "audio/flac", "audio/x-flac":
+		return "flac"
+	case "audio/aac":
+		return "aac"
+	}
+	return "audio"
+}
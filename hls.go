@@ -0,0 +1,175 @@
+package soundcloudapi
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafov/m3u8"
+	"github.com/pkg/errors"
+)
+
+// VariantPolicy selects the variant of an HLS master playlist to download. Of the variants
+// the policy allows, the one with the highest bandwidth is picked. The zero value allows every variant.
+type VariantPolicy struct {
+	Codec        string // if set, only variants with a codec starting with Codec are allowed, e.g. "mp4a" or "opus"
+	MaxBandwidth uint32 // if non-zero, only variants with a bandwidth of at most MaxBandwidth bits per second are allowed
+}
+
+// ErrNoMatchingVariant is returned when the VariantPolicy allows no variant of an HLS master playlist
+var ErrNoMatchingVariant = errors.New("No HLS variant matches the variant policy")
+
+func (p VariantPolicy) allows(variant *m3u8.Variant) bool {
+	if variant.Iframe || (p.MaxBandwidth != 0 && variant.Bandwidth > p.MaxBandwidth) {
+		return false
+	}
+	if p.Codec == "" {
+		return true
+	}
+	for _, codec := range strings.Split(variant.Codecs, ",") {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(codec)), strings.ToLower(p.Codec)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p VariantPolicy) selectVariant(variants []*m3u8.Variant) (*m3u8.Variant, error) {
+	var selected *m3u8.Variant
+	for _, variant := range variants {
+		if variant != nil && p.allows(variant) && (selected == nil || variant.Bandwidth > selected.Bandwidth) {
+			selected = variant
+		}
+	}
+	if selected == nil {
+		return nil, ErrNoMatchingVariant
+	}
+	return selected, nil
+}
+
+// getMediaPlaylist fetches the HLS media playlist at playlistURL. If it is a master playlist, the variant
+// selected by the client's VariantPolicy is fetched instead. The media playlist is returned with its URL.
+func (c *client) getMediaPlaylist(ctx context.Context, playlistURL string) (*m3u8.MediaPlaylist, string, error) {
+	for master := false; ; master = true {
+		m3u8Raw, err := c.getMedia(ctx, playlistURL)
+		if err != nil {
+			return nil, "", err
+		}
+
+		playlist, listType, err := m3u8.Decode(*bytes.NewBuffer(m3u8Raw), true)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "Failed to decode m3u8 playlist")
+		}
+
+		if mediaPlaylist, ok := playlist.(*m3u8.MediaPlaylist); ok && listType == m3u8.MEDIA {
+			return mediaPlaylist, playlistURL, nil
+		}
+		masterPlaylist, ok := playlist.(*m3u8.MasterPlaylist)
+		if !ok || listType != m3u8.MASTER || master {
+			return nil, "", errors.New("m3u8 playlist is not a media playlist")
+		}
+
+		variant, err := c.hlsVariant.selectVariant(masterPlaylist.Variants)
+		if err != nil {
+			return nil, "", err
+		}
+		base, err := url.Parse(playlistURL)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "Failed to parse playlist URL")
+		}
+		if playlistURL, err = resolveURI(base, variant.URI); err != nil {
+			return nil, "", err
+		}
+	}
+}
+
+func resolveURI(base *url.URL, uri string) (string, error) {
+	ref, err := url.Parse(uri)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to parse HLS URI")
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// hlsPart is a part of a media playlist to download, either a segment or the initialization
+// section of the segments after it
+type hlsPart struct {
+	URI      string // absolute URI of the file containing the part
+	Offset   int64  // offset of the part in the file
+	Limit    int64  // length of the part, 0 if the part is the whole file
+	Duration time.Duration
+	Init     bool
+	Key      *hlsKey // nil if the part isn't encrypted
+}
+
+// hlsParts returns the parts of playlist in the order they must be written.
+// Relative URIs are resolved against playlistURL.
+func hlsParts(playlistURL string, playlist *m3u8.MediaPlaylist) ([]hlsPart, error) {
+	base, err := url.Parse(playlistURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse playlist URL")
+	}
+
+	parts := []hlsPart{}
+	var initMap *m3u8.Map
+	var previous hlsPart
+	var key *m3u8.Key
+	sequence := playlist.SeqNo
+	for _, segment := range playlist.Segments {
+		if segment == nil {
+			continue
+		}
+
+		// Like EXT-X-MAP, EXT-X-KEY applies to every segment after it until the next one
+		if segment.Key != nil {
+			key = segment.Key
+		}
+		partKey, err := newHLSKey(base, key, sequence)
+		if err != nil {
+			return nil, err
+		}
+		sequence++
+
+		// EXT-X-MAP applies to every segment after it until the next one, so the initialization
+		// section is only written again when it changes
+		if segment.Map != nil && (initMap == nil || *segment.Map != *initMap) {
+			uri, err := resolveURI(base, segment.Map.URI)
+			if err != nil {
+				return nil, err
+			}
+			// An encrypted initialization section must have an explicit IV, it has no sequence number
+			// to take it from, so one without an IV isn't encrypted
+			initKey := partKey
+			if key == nil || key.IV == "" {
+				initKey = nil
+			}
+			parts = append(parts, hlsPart{URI: uri, Offset: segment.Map.Offset, Limit: segment.Map.Limit, Init: true, Key: initKey})
+			initMap = segment.Map
+		}
+
+		uri, err := resolveURI(base, segment.URI)
+		if err != nil {
+			return nil, err
+		}
+		part := hlsPart{
+			URI:      uri,
+			Offset:   segment.Offset,
+			Limit:    segment.Limit,
+			Duration: time.Duration(segment.Duration * float64(time.Second)),
+			Key:      partKey,
+		}
+
+		// An EXT-X-BYTERANGE without an offset starts after the previous byte range of the same file,
+		// but the decoder reports its offset as 0
+		if part.Limit > 0 && part.Offset == 0 && previous.Limit > 0 && previous.URI == part.URI {
+			part.Offset = previous.Offset + previous.Limit
+		}
+
+		parts = append(parts, part)
+		previous = part
+	}
+
+	return parts, nil
+}
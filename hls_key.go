@@ -0,0 +1,137 @@
+package soundcloudapi
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/grafov/m3u8"
+	"github.com/pkg/errors"
+)
+
+// UnsupportedEncryptionError is returned when an HLS playlist is encrypted with a method other than AES-128
+type UnsupportedEncryptionError struct {
+	Method    string
+	KeyFormat string // empty for the default "identity" key format
+}
+
+func (e *UnsupportedEncryptionError) Error() string {
+	if e.KeyFormat != "" {
+		return fmt.Sprintf("Unsupported HLS encryption method %s with key format %s", e.Method, e.KeyFormat)
+	}
+	return fmt.Sprintf("Unsupported HLS encryption method %s", e.Method)
+}
+
+// hlsKey is the AES-128 key and IV a part of an HLS playlist is encrypted with
+type hlsKey struct {
+	URI string // absolute URI of the key
+	IV  []byte
+}
+
+// newHLSKey returns the key of a segment with the given media sequence number, or nil if key
+// doesn't encrypt it
+func newHLSKey(base *url.URL, key *m3u8.Key, sequence uint64) (*hlsKey, error) {
+	if key == nil || key.Method == "" || key.Method == "NONE" {
+		return nil, nil
+	}
+	if key.Method != "AES-128" || (key.Keyformat != "" && key.Keyformat != "identity") {
+		return nil, &UnsupportedEncryptionError{Method: key.Method, KeyFormat: key.Keyformat}
+	}
+
+	uri, err := resolveURI(base, key.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if key.IV != "" {
+		raw := strings.TrimPrefix(strings.TrimPrefix(key.IV, "0x"), "0X")
+		decoded, err := hex.DecodeString(raw)
+		if err != nil || len(decoded) > aes.BlockSize {
+			return nil, errors.Errorf("Invalid HLS key IV %s", key.IV)
+		}
+		copy(iv[aes.BlockSize-len(decoded):], decoded)
+	} else {
+		// Without an IV attribute, the IV is the media sequence number of the segment
+		binary.BigEndian.PutUint64(iv[8:], sequence)
+	}
+
+	return &hlsKey{URI: uri, IV: iv}, nil
+}
+
+// hlsKeyCache fetches each key of an HLS playlist once, however many segments use it
+type hlsKeyCache struct {
+	client *client
+	mu     sync.Mutex
+	keys   map[string]*hlsKeyEntry
+}
+
+type hlsKeyEntry struct {
+	done chan struct{} // closed when the key is fetched
+	key  []byte
+	err  error
+}
+
+func newHLSKeyCache(c *client) *hlsKeyCache {
+	return &hlsKeyCache{client: c, keys: map[string]*hlsKeyEntry{}}
+}
+
+func (k *hlsKeyCache) get(ctx context.Context, uri string) ([]byte, error) {
+	k.mu.Lock()
+	entry, ok := k.keys[uri]
+	if !ok {
+		entry = &hlsKeyEntry{done: make(chan struct{})}
+		k.keys[uri] = entry
+	}
+	k.mu.Unlock()
+
+	if !ok {
+		entry.key, entry.err = k.client.getMedia(ctx, uri)
+		if entry.err == nil && len(entry.key) != aes.BlockSize {
+			entry.err = errors.Errorf("Expected an HLS key of %d bytes, received %d", aes.BlockSize, len(entry.key))
+		}
+		close(entry.done)
+	}
+
+	select {
+	case <-entry.done:
+		return entry.key, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// decrypt decrypts data, which is encrypted with key using AES-128 in CBC mode with PKCS7 padding
+func (k *hlsKeyCache) decrypt(ctx context.Context, key *hlsKey, data []byte) ([]byte, error) {
+	secret, err := k.get(ctx, key.URI)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch HLS key")
+	}
+
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.Errorf("Encrypted HLS segment size %d is not a multiple of the AES block size", len(data))
+	}
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create AES cipher")
+	}
+	decrypted := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, key.IV).CryptBlocks(decrypted, data)
+
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, errors.New("Invalid PKCS7 padding of decrypted HLS segment")
+	}
+	for _, b := range decrypted[len(decrypted)-padding:] {
+		if int(b) != padding {
+			return nil, errors.New("Invalid PKCS7 padding of decrypted HLS segment")
+		}
+	}
+	return decrypted[:len(decrypted)-padding], nil
+}
@@ -0,0 +1,251 @@
+package soundcloudapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// ID3Tag is the metadata written into an ID3v2.4 tag
+type ID3Tag struct {
+	Title       string
+	Artist      string
+	Genre       string
+	Tags        []string // written as a user defined "Tags" text frame
+	Label       string
+	Year        string
+	URL         string
+	Comment     string
+	Artwork     []byte // front cover image
+	ArtworkMIME string // mime type of Artwork, defaults to image/jpeg
+}
+
+// NewID3Tag returns an ID3Tag with the metadata of track, without artwork
+func NewID3Tag(track Track) ID3Tag {
+	tag := ID3Tag{
+		Title:   track.Title,
+		Artist:  track.User.Username,
+		Genre:   track.Genre,
+		Tags:    ParseTagList(track.TagList),
+		Label:   track.LabelName,
+		URL:     track.PermalinkURL,
+		Comment: track.Description,
+	}
+
+	// The display date is an ISO 8601 timestamp like 2020-10-30T12:00:00Z
+	if len(track.DisplayDate) >= 4 {
+		tag.Year = track.DisplayDate[:4]
+	}
+
+	return tag
+}
+
+// id3Tag returns the ID3Tag of track with its artwork, or the avatar of its user if it has none.
+// An error is returned if the artwork can't be downloaded, and artwork that isn't an image is left out.
+func (c *client) id3Tag(ctx context.Context, track Track) (ID3Tag, error) {
+	tag := NewID3Tag(track)
+
+	artworkURL := track.ArtworkURL
+	if artworkURL == "" {
+		artworkURL = track.User.AvatarURL
+	}
+	if artworkURL == "" {
+		return tag, nil
+	}
+
+	artwork, err := c.getMedia(ctx, LargeArtworkURL(artworkURL))
+	if err != nil {
+		return tag, errors.Wrap(err, "Failed to download artwork")
+	}
+	if mime := http.DetectContentType(artwork); strings.HasPrefix(mime, "image/") {
+		tag.Artwork = artwork
+		tag.ArtworkMIME = mime
+	}
+
+	return tag, nil
+}
+
+// ParseTagList splits a track's tag list, in which tags are separated by spaces
+// and tags with spaces are quoted, e.g. `lofi "hip hop" chill`
+func ParseTagList(tagList string) []string {
+	tags := []string{}
+	quoted := false
+	current := strings.Builder{}
+	flush := func() {
+		if current.Len() > 0 {
+			tags = append(tags, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range tagList {
+		switch {
+		case r == '"':
+			flush()
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tags
+}
+
+// LargeArtworkURL returns the URL of the largest version of an artwork or avatar URL,
+// which is 500x500 pixels
+func LargeArtworkURL(url string) string {
+	return strings.Replace(url, "-large.", "-t500x500.", 1)
+}
+
+// Bytes encodes the tag, only the fields that aren't empty are included
+func (t ID3Tag) Bytes() []byte {
+	frames := &bytes.Buffer{}
+	writeID3TextFrame(frames, "TIT2", t.Title)
+	writeID3TextFrame(frames, "TPE1", t.Artist)
+	writeID3TextFrame(frames, "TCON", t.Genre)
+	writeID3TextFrame(frames, "TPUB", t.Label)
+	writeID3TextFrame(frames, "TDRC", t.Year)
+
+	if len(t.Tags) > 0 {
+		// TXXX: encoding, description, value
+		writeID3Frame(frames, "TXXX", []byte("\x03Tags\x00"+strings.Join(t.Tags, "\x00")))
+	}
+
+	if t.URL != "" {
+		// URL frames are always ISO-8859-1 without an encoding byte
+		writeID3Frame(frames, "WOAF", []byte(t.URL))
+	}
+
+	if t.Comment != "" {
+		// COMM: encoding, language, empty description, text
+		writeID3Frame(frames, "COMM", []byte("\x03eng\x00"+t.Comment))
+	}
+
+	if len(t.Artwork) > 0 {
+		mime := t.ArtworkMIME
+		if mime == "" {
+			mime = "image/jpeg"
+		}
+		// APIC: encoding, mime type, picture type (3 is the front cover), empty description, data
+		data := append([]byte("\x03"+mime+"\x00\x03\x00"), t.Artwork...)
+		writeID3Frame(frames, "APIC", data)
+	}
+
+	tag := &bytes.Buffer{}
+	tag.WriteString("ID3\x04\x00\x00")
+	tag.Write(synchsafe(frames.Len()))
+	tag.Write(frames.Bytes())
+	return tag.Bytes()
+}
+
+func writeID3TextFrame(w *bytes.Buffer, id string, text string) {
+	if text == "" {
+		return
+	}
+	// Encoding 3 is UTF-8
+	writeID3Frame(w, id, []byte("\x03"+text))
+}
+
+func writeID3Frame(w *bytes.Buffer, id string, data []byte) {
+	w.WriteString(id)
+	w.Write(synchsafe(len(data)))
+	w.Write([]byte{0, 0})
+	w.Write(data)
+}
+
+// synchsafe encodes n in 4 bytes of 7 bits, as required for the sizes of ID3v2.4 tags and frames
+func synchsafe(n int) []byte {
+	return []byte{byte(n>>21) & 0x7f, byte(n>>14) & 0x7f, byte(n>>7) & 0x7f, byte(n) & 0x7f}
+}
+
+func unsynchsafe(b []byte) int64 {
+	return int64(b[0]&0x7f)<<21 | int64(b[1]&0x7f)<<14 | int64(b[2]&0x7f)<<7 | int64(b[3]&0x7f)
+}
+
+// id3HeaderSize is the size of an ID3v2 header and footer
+const id3HeaderSize = 10
+
+// ID3Writer writes an ID3v2 tag followed by the MP3 audio written to it. An ID3v2 tag at the
+// start of the audio is replaced.
+type ID3Writer struct {
+	dst     io.Writer
+	tag     []byte
+	head    []byte // buffers the start of the audio until it's known whether it's an ID3v2 tag
+	started bool
+	skip    int64 // bytes left of the ID3v2 tag being replaced
+}
+
+// NewID3Writer returns an ID3Writer that writes tag and the audio to dst.
+// Close must be called after the audio was written.
+func NewID3Writer(dst io.Writer, tag ID3Tag) *ID3Writer {
+	return &ID3Writer{dst: dst, tag: tag.Bytes()}
+}
+
+// Write writes audio
+func (w *ID3Writer) Write(p []byte) (int, error) {
+	n := len(p)
+
+	if !w.started {
+		missing := id3HeaderSize - len(w.head)
+		if len(p) < missing {
+			w.head = append(w.head, p...)
+			return n, nil
+		}
+		w.head = append(w.head, p[:missing]...)
+		p = p[missing:]
+
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.skip > 0 {
+		if int64(len(p)) <= w.skip {
+			w.skip -= int64(len(p))
+			return n, nil
+		}
+		p = p[w.skip:]
+		w.skip = 0
+	}
+
+	if _, err := w.dst.Write(p); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// start writes the tag, and the start of the audio unless it's the header of a tag to replace
+func (w *ID3Writer) start() error {
+	w.started = true
+	if _, err := w.dst.Write(w.tag); err != nil {
+		return err
+	}
+
+	if len(w.head) == id3HeaderSize && bytes.HasPrefix(w.head, []byte("ID3")) {
+		w.skip = unsynchsafe(w.head[6:10])
+		if w.head[5]&0x10 != 0 {
+			// The tag has a footer
+			w.skip += id3HeaderSize
+		}
+		return nil
+	}
+
+	_, err := w.dst.Write(w.head)
+	return err
+}
+
+// Close writes the tag if less than an ID3v2 header's worth of audio was written
+func (w *ID3Writer) Close() error {
+	if w.started {
+		return nil
+	}
+	return w.start()
+}
@@ -0,0 +1,120 @@
+package soundcloudapi
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoMorePages is returned by Pager.Next when every page was fetched
+var ErrNoMorePages = errors.New("No more pages")
+
+// Pager fetches the pages of a paginated query one after another by following their next_href
+type Pager struct {
+	MaxPages int // maximum number of pages to fetch, 0 means no limit
+	MaxItems int // maximum number of items to fetch, the last page is truncated to fit. 0 means no limit
+
+	client   *client
+	first    func(ctx context.Context) (*PaginatedQuery, error)
+	nextHref string
+	started  bool
+	done     bool
+	pages    int
+	items    int
+}
+
+// LikesPager returns a Pager over a user's likes, starting at the page selected by options
+func (sc *API) LikesPager(options GetLikesOptions) *Pager {
+	return &Pager{
+		client: sc.client,
+		first: func(ctx context.Context) (*PaginatedQuery, error) {
+			return sc.GetLikesContext(ctx, options)
+		},
+	}
+}
+
+// SearchPager returns a Pager over the results of a search, starting at the page selected by options
+func (sc *API) SearchPager(options SearchOptions) *Pager {
+	return &Pager{
+		client: sc.client,
+		first: func(ctx context.Context) (*PaginatedQuery, error) {
+			return sc.SearchContext(ctx, options)
+		},
+	}
+}
+
+// More returns true if Next can fetch another page
+func (p *Pager) More() bool {
+	return !p.done
+}
+
+// Next fetches the next page. ErrNoMorePages is returned when the last page was fetched,
+// or when MaxPages or MaxItems was reached.
+func (p *Pager) Next(ctx context.Context) (*PaginatedQuery, error) {
+	if p.done {
+		return nil, ErrNoMorePages
+	}
+
+	var page *PaginatedQuery
+	var err error
+	if !p.started {
+		page, err = p.first(ctx)
+	} else {
+		page, err = p.client.getPage(ctx, p.nextHref)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.started = true
+	p.pages++
+	p.nextHref = page.NextHref
+
+	if p.MaxItems > 0 && p.items+len(page.Collection) >= p.MaxItems {
+		page.Collection = page.Collection[:p.MaxItems-p.items]
+		p.done = true
+	}
+	p.items += len(page.Collection)
+
+	if page.NextHref == "" || (p.MaxPages > 0 && p.pages >= p.MaxPages) {
+		p.done = true
+	}
+
+	return page, nil
+}
+
+// ForEach calls fn with every remaining page. If fn returns an error, ForEach stops and returns it.
+func (p *Pager) ForEach(ctx context.Context, fn func(page *PaginatedQuery) error) error {
+	for p.More() {
+		page, err := p.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// All fetches every remaining page and merges them into one PaginatedQuery with up to max items,
+// 0 means no limit besides MaxPages and MaxItems.
+func (p *Pager) All(ctx context.Context, max int) (*PaginatedQuery, error) {
+	if max > 0 && (p.MaxItems == 0 || p.items+max < p.MaxItems) {
+		p.MaxItems = p.items + max
+	}
+
+	all := &PaginatedQuery{Collection: []map[string]interface{}{}}
+	err := p.ForEach(ctx, func(page *PaginatedQuery) error {
+		all.Collection = append(all.Collection, page.Collection...)
+		all.TotalResults = page.TotalResults
+		all.QueryURN = page.QueryURN
+		all.NextHref = page.NextHref
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
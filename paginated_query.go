@@ -84,3 +84,29 @@ func (pq *PaginatedQuery) GetLikes() ([]Like, error) {
 
 	return likes, nil
 }
+
+// GetUsers returns any of the items in the PaginatedQuery's collection that match the User struct type
+func (pq *PaginatedQuery) GetUsers() ([]User, error) {
+	users := make([]User, 0)
+
+	for _, item := range pq.Collection {
+		user := User{}
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to marshal PaginatedQuery collection item")
+		}
+
+		err = json.Unmarshal(b, &user)
+		if err != nil {
+			continue
+		}
+
+		if user.Kind != "user" {
+			continue
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,279 @@
+package soundcloudapi
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultPlaylistWorkers is the default number of tracks DownloadPlaylist downloads concurrently
+const DefaultPlaylistWorkers = 4
+
+// DefaultPlaylistTemplate is the default path template of the tracks downloaded by DownloadPlaylist
+const DefaultPlaylistTemplate = `{{printf "%02d" .Index}} - {{.User.Username}} - {{.Title}}.{{.Ext}}`
+
+// DefaultPlaylistIndex is the default name of the M3U8 index written by DownloadPlaylist
+const DefaultPlaylistIndex = "playlist.m3u8"
+
+// ErrBlocked is returned when downloading a track SoundCloud blocks, usually in the client's region
+var ErrBlocked = errors.New("Track is blocked")
+
+// PlaylistDownloadOptions are the options of DownloadPlaylist
+type PlaylistDownloadOptions struct {
+	Workers  int               // number of tracks downloaded concurrently, defaults to DefaultPlaylistWorkers
+	Template string            // FilenameTemplate of each track's path relative to the directory, defaults to DefaultPlaylistTemplate
+	Policy   TranscodingPolicy // picks the transcoding of each track
+	Index    string            // name of the M3U8 index written to the directory, defaults to DefaultPlaylistIndex
+	NoIndex  bool              // whether or not to skip writing the M3U8 index
+	Archive  *Archive          // if set, tracks it has as downloaded or failed too many times are skipped, and it records the downloads
+	Tag      bool              // whether or not to write an ID3v2 tag to audio/mpeg tracks, like DownloadOptions.Tag
+
+	// Progress is called with the progress of each track's download, see DownloadOptions.Progress. result is the
+	// track's result so far, without Err. It's called from the goroutines downloading the tracks, so it must be
+	// safe for concurrent use.
+	Progress func(result PlaylistTrackResult, progress Progress)
+
+	// OnResult is called once for every track when it is done, even if it was never downloaded because the
+	// context was cancelled, from the goroutine that called DownloadPlaylist
+	OnResult func(PlaylistTrackResult)
+}
+
+// PlaylistTrackResult is the result of downloading a track of a playlist
+type PlaylistTrackResult struct {
+	Index       int // position of the track in the playlist, starting at 1
+	Track       Track
+	Transcoding Transcoding
+	Path        string // path of the file relative to the directory, empty if the path template failed
+	Skipped     bool   // whether the Archive had the track as downloaded, or failed too many times with ErrTooManyFailures
+	Err         error
+}
+
+// DownloadPlaylist downloads the tracks of playlist to files in dir and writes an M3U8 index of the
+// downloaded tracks, unless NoIndex is set. A track failing doesn't stop the others from downloading,
+// each track's result is returned in the order of the playlist. The error is only set if the index couldn't be written,
+// or if the context was cancelled.
+func (sc *API) DownloadPlaylist(playlist Playlist, dir string, options PlaylistDownloadOptions) ([]PlaylistTrackResult, error) {
+	return sc.DownloadPlaylistContext(context.Background(), playlist, dir, options)
+}
+
+// DownloadPlaylistContext is like DownloadPlaylist but with a context
+func (sc *API) DownloadPlaylistContext(ctx context.Context, playlist Playlist, dir string, options PlaylistDownloadOptions) ([]PlaylistTrackResult, error) {
+	if options.Index == "" {
+		options.Index = DefaultPlaylistIndex
+	}
+	results, err := sc.downloadTracks(ctx, playlist, dir, options, nil)
+	if err != nil || options.NoIndex {
+		return results, err
+	}
+	if err := writeM3U8(filepath.Join(dir, options.Index), playlist, results); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// downloadTracks downloads the tracks of playlist like DownloadPlaylist, without writing an index.
+// The paths in taken are used by other files, the tracks are never downloaded to them.
+func (sc *API) downloadTracks(ctx context.Context, playlist Playlist, dir string, options PlaylistDownloadOptions, taken []string) ([]PlaylistTrackResult, error) {
+	if options.Workers <= 0 {
+		options.Workers = DefaultPlaylistWorkers
+	}
+	if options.Template == "" {
+		options.Template = DefaultPlaylistTemplate
+	}
+	tmpl, err := NewFilenameTemplate(options.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	// The paths are chosen up front so that duplicates are renamed in the order of the playlist,
+	// including the tracks that are skipped so that the same paths are chosen every time
+	results := make([]PlaylistTrackResult, len(playlist.Tracks))
+	named := []int{}
+	paths := []string{}
+	// A track that is in the playlist more than once is downloaded once, and its other entries get the same result.
+	// The tracks without an ID, like the ones the playlist didn't include, aren't the same track.
+	first := map[int64]int{}
+	duplicates := []int{}
+	for i, track := range playlist.Tracks {
+		results[i] = PlaylistTrackResult{Index: i + 1, Track: track}
+		if _, ok := first[track.ID]; ok && track.ID != 0 {
+			duplicates = append(duplicates, i)
+			continue
+		}
+		if track.ID != 0 {
+			first[track.ID] = i
+		}
+		if results[i].Err = preparePlaylistTrack(tmpl, playlist, &results[i], options.Policy); results[i].Err == nil {
+			named = append(named, i)
+			paths = append(paths, results[i].Path)
+		}
+	}
+	for j, path := range DedupePaths(append(append([]string{}, taken...), paths...))[len(taken):] {
+		results[named[j]].Path = path
+	}
+
+	pending := []int{}
+	for i := range results {
+		result := &results[i]
+		if result.Track.ID != 0 && first[result.Track.ID] != i {
+			continue
+		}
+		if result.Err == nil && options.Archive != nil && !options.Archive.ShouldDownload(result.Track.ID, result.Transcoding) {
+			result.Skipped = true
+			if entry, _ := options.Archive.Entry(result.Track.ID, result.Transcoding); entry.Status != ArchiveDownloaded {
+				result.Err = ErrTooManyFailures
+			}
+		}
+
+		if result.Err == nil && !result.Skipped {
+			pending = append(pending, i)
+		} else if options.OnResult != nil {
+			options.OnResult(*result)
+		}
+	}
+
+	jobs := make(chan int)
+	done := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i].Err = sc.downloadPlaylistTrack(ctx, dir, results[i], options)
+				done <- i
+			}
+		}()
+	}
+
+	// dispatched is only read once every worker is done, after the jobs channel was closed
+	dispatched := len(pending)
+	go func() {
+		defer close(jobs)
+		for j, i := range pending {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				dispatched = j
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for i := range done {
+		if options.OnResult != nil {
+			options.OnResult(results[i])
+		}
+	}
+
+	// The tracks left when the context was cancelled are never downloaded
+	for _, i := range pending[dispatched:] {
+		results[i].Err = ctx.Err()
+		if options.OnResult != nil {
+			options.OnResult(results[i])
+		}
+	}
+
+	for _, i := range duplicates {
+		result := results[first[results[i].Track.ID]]
+		result.Index = i + 1
+		results[i] = result
+		if options.OnResult != nil {
+			options.OnResult(result)
+		}
+	}
+
+	return results, ctx.Err()
+}
+
+// preparePlaylistTrack picks the transcoding and the path of the track of result
+func preparePlaylistTrack(tmpl *FilenameTemplate, playlist Playlist, result *PlaylistTrackResult, policy TranscodingPolicy) error {
+	if result.Track.IsBlocked() {
+		return ErrBlocked
+	}
+
+	var err error
+	result.Transcoding, err = SelectTranscoding(result.Track, policy)
+	if err != nil {
+		if previewOnly(result.Track) {
+			return newPreviewOnlyError(result.Track)
+		}
+		return err
+	}
+
+	file := NewTrackFile(result.Track, result.Transcoding)
+	file.Index = result.Index
+	file.Playlist = playlist
+	result.Path, err = tmpl.Execute(file)
+	return err
+}
+
+func (sc *API) downloadPlaylistTrack(ctx context.Context, dir string, result PlaylistTrackResult, options PlaylistDownloadOptions) error {
+	downloadOptions := DownloadOptions{}
+	if options.Tag {
+		downloadOptions.Tag = &result.Track
+	}
+	if options.Progress != nil {
+		downloadOptions.Progress = func(progress Progress) {
+			options.Progress(result, progress)
+		}
+	}
+
+	path := filepath.Join(dir, result.Path)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		err = errors.Wrap(err, "Failed to create directory")
+	} else {
+		// The policy already decided whether the transcoding can be a preview
+		err = sc.downloadTrackToFile(ctx, result.Transcoding, path, downloadOptions)
+	}
+
+	return archiveResult(ctx, options.Archive, result.Track.ID, result.Transcoding, path, err)
+}
+
+// archiveResult records the result of downloading a transcoding of a track in archive, if it isn't nil.
+// Downloads stopped by ctx aren't failures. err is returned, or the error writing the archive.
+func archiveResult(ctx context.Context, archive *Archive, trackID int64, transcoding Transcoding, path string, err error) error {
+	if archive == nil || ctx.Err() != nil {
+		return err
+	}
+	if err != nil {
+		// Failing to record the failure only means the track is retried once more
+		archive.Failed(trackID, transcoding, err)
+		return err
+	}
+	return archive.Downloaded(trackID, transcoding, path)
+}
+
+// writeM3U8 writes an extended M3U8 playlist of the tracks of results that were downloaded to path
+func writeM3U8(path string, playlist Playlist, results []PlaylistTrackResult) error {
+	// Titles can't span lines
+	line := strings.NewReplacer("\r", " ", "\n", " ")
+
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "#EXTM3U\n#PLAYLIST:%s\n", line.Replace(playlist.Title))
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		seconds := (result.Track.DurationMS + 500) / 1000
+		fmt.Fprintf(buf, "#EXTINF:%d,%s - %s\n", seconds, line.Replace(result.Track.User.Username), line.Replace(result.Track.Title))
+		fmt.Fprintf(buf, "%s\n", filepath.ToSlash(result.Path))
+	}
+
+	tmp := path + partSuffix
+	if err := ioutil.WriteFile(tmp, []byte(buf.String()), 0644); err != nil {
+		return errors.Wrap(err, "Failed to write M3U8 index")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "Failed to write M3U8 index")
+}
@@ -0,0 +1,80 @@
+package soundcloudapi
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrPreviewOnly is the cause of every *PreviewOnlyError
+var ErrPreviewOnly = errors.New("Only a preview of the track is available")
+
+// PreviewOnlyError is returned when downloading a track of which SoundCloud only serves a snipped
+// preview, usually because the track is Go+ only or not available in the region.
+// Set APIOptions.AllowPreviews, or TranscodingPolicy.AllowSnipped when downloading with a policy,
+// to download previews anyway.
+type PreviewOnlyError struct {
+	TrackID        int64 // 0 if unknown
+	DurationMS     int64 // duration of the preview, 0 if unknown
+	FullDurationMS int64 // duration of the full track, 0 if unknown
+}
+
+func newPreviewOnlyError(track Track) *PreviewOnlyError {
+	return &PreviewOnlyError{TrackID: track.ID, DurationMS: track.DurationMS, FullDurationMS: track.FullDurationMS}
+}
+
+func (e *PreviewOnlyError) Error() string {
+	if e.DurationMS == 0 || e.FullDurationMS == 0 {
+		return fmt.Sprintf("Only a preview of track %d is available", e.TrackID)
+	}
+	return fmt.Sprintf("Only a %s preview of track %d is available, the full track is %s",
+		time.Duration(e.DurationMS)*time.Millisecond, e.TrackID, time.Duration(e.FullDurationMS)*time.Millisecond)
+}
+
+// Cause returns ErrPreviewOnly
+func (e *PreviewOnlyError) Cause() error {
+	return ErrPreviewOnly
+}
+
+// Unwrap returns ErrPreviewOnly
+func (e *PreviewOnlyError) Unwrap() error {
+	return ErrPreviewOnly
+}
+
+// previewOnly returns true if every transcoding of track is a snipped preview
+func previewOnly(track Track) bool {
+	for _, transcoding := range track.Media.Transcodings {
+		if !transcoding.Snipped {
+			return false
+		}
+	}
+	return len(track.Media.Transcodings) > 0
+}
+
+var transcodingTrackIDRegex = regexp.MustCompile(`soundcloud:tracks:(\d+)`)
+
+// checkPreview returns a *PreviewOnlyError if transcoding is a preview and previews aren't allowed.
+// The durations are taken from track, which is fetched if nil, and left out if it can't be.
+func (sc *API) checkPreview(ctx context.Context, transcoding Transcoding, track *Track) error {
+	if !transcoding.Snipped || sc.AllowPreviews {
+		return nil
+	}
+
+	if track != nil {
+		return newPreviewOnlyError(*track)
+	}
+
+	previewErr := &PreviewOnlyError{}
+	if match := transcodingTrackIDRegex.FindStringSubmatch(transcoding.URL); match != nil {
+		previewErr.TrackID, _ = strconv.ParseInt(match[1], 10, 64)
+		tracks, err := sc.client.getTrackInfo(ctx, GetTrackInfoOptions{ID: []int64{previewErr.TrackID}})
+		if err == nil && len(tracks) > 0 {
+			return newPreviewOnlyError(tracks[0])
+		}
+	}
+	return previewErr
+}
@@ -0,0 +1,124 @@
+package soundcloudapi
+
+import (
+	"io"
+	"time"
+)
+
+// Progress describes how far a download got
+type Progress struct {
+	BytesWritten int64 // bytes written to dst so far
+	TotalBytes   int64 // expected size of the track, -1 if unknown (HLS)
+
+	SegmentsCompleted int           // HLS segments written to dst so far
+	TotalSegments     int           // number of HLS segments, 0 for progressive downloads
+	Duration          time.Duration // duration of the HLS segments written to dst so far
+	TotalDuration     time.Duration // duration of every HLS segment, 0 for progressive downloads
+
+	Elapsed        time.Duration // time since the download started
+	BytesPerSecond float64       // throughput over the last 10 reports, or since the download started before that
+}
+
+// ProgressFunc is called with the progress of a download
+type ProgressFunc func(progress Progress)
+
+// progressWindow is the number of reports Progress.BytesPerSecond is measured over, so that it follows
+// changes in throughput instead of averaging the whole download
+const progressWindow = 10
+
+// progressSample is the number of bytes written at the time of a report
+type progressSample struct {
+	elapsed time.Duration
+	bytes   int64
+}
+
+// progressReporter keeps track of the progress of a download. A nil progressReporter reports nothing.
+type progressReporter struct {
+	fn       ProgressFunc
+	start    time.Time
+	progress Progress
+	samples  [progressWindow]progressSample // the last reports, samples[reports%progressWindow] is the oldest
+	reports  int
+	base     progressSample // the throughput is measured from it until there are enough reports
+}
+
+func newProgressReporter(fn ProgressFunc) *progressReporter {
+	if fn == nil {
+		return nil
+	}
+	return &progressReporter{
+		fn:       fn,
+		start:    time.Now(),
+		progress: Progress{TotalBytes: -1},
+	}
+}
+
+// setTotal sets the expected size of the download and reports it
+func (r *progressReporter) setTotal(bytes int64, segments int, duration time.Duration) {
+	if r == nil {
+		return
+	}
+	r.progress.TotalBytes = bytes
+	r.progress.TotalSegments = segments
+	r.progress.TotalDuration = duration
+	r.report()
+}
+
+// restart sets the bytes written so far, when a download restarts or resumes, without counting
+// them towards the throughput
+func (r *progressReporter) restart(bytes int64) {
+	if r == nil {
+		return
+	}
+	r.progress.BytesWritten = bytes
+	r.base = progressSample{elapsed: time.Since(r.start), bytes: bytes}
+	r.reports = 0
+}
+
+// wrote reports that n bytes were written
+func (r *progressReporter) wrote(n int) {
+	if r == nil {
+		return
+	}
+	r.progress.BytesWritten += int64(n)
+	r.report()
+}
+
+// wroteSegment reports that an HLS segment of n bytes and the given duration was written
+func (r *progressReporter) wroteSegment(n int, duration time.Duration) {
+	if r == nil {
+		return
+	}
+	r.progress.SegmentsCompleted++
+	r.progress.Duration += duration
+	r.wrote(n)
+}
+
+func (r *progressReporter) report() {
+	r.progress.Elapsed = time.Since(r.start)
+
+	// Until there are enough reports, the throughput is measured since the start or the last restart
+	oldest := r.base
+	if r.reports >= progressWindow {
+		oldest = r.samples[r.reports%progressWindow]
+	}
+	r.samples[r.reports%progressWindow] = progressSample{elapsed: r.progress.Elapsed, bytes: r.progress.BytesWritten}
+	r.reports++
+	if seconds := (r.progress.Elapsed - oldest.elapsed).Seconds(); seconds > 0 {
+		r.progress.BytesPerSecond = float64(r.progress.BytesWritten-oldest.bytes) / seconds
+	}
+
+	r.fn(r.progress)
+}
+
+// progressWriter reports every write to dst
+type progressWriter struct {
+	dst      io.Writer
+	reporter *progressReporter
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.dst.Write(p)
+	w.reporter.wrote(n)
+	return n, err
+}
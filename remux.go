@@ -0,0 +1,39 @@
+package soundcloudapi
+
+import (
+	"io"
+	"strings"
+)
+
+// assembler assembles the HLS segments written to it into a file written to dst
+type assembler interface {
+	io.Writer
+
+	// Close finishes the file after every segment was written
+	Close() error
+
+	// Discard releases the resources of the assembler after a failed download, without finishing the file
+	Discard()
+}
+
+// newAssembler returns the assembler for HLS segments of the given mime type. Fragmented MP4 segments are
+// remuxed into an M4A file and Ogg Opus segments into a single Ogg stream, other segments are concatenated.
+func newAssembler(dst io.Writer, mimeType string) assembler {
+	switch {
+	case strings.HasPrefix(mimeType, "audio/mp4"):
+		return &m4aAssembler{dst: dst}
+	case strings.HasPrefix(mimeType, "audio/ogg"):
+		return &oggAssembler{dst: dst}
+	default:
+		return concatAssembler{dst}
+	}
+}
+
+// concatAssembler concatenates segments, which is enough for MPEG audio
+type concatAssembler struct {
+	io.Writer
+}
+
+func (concatAssembler) Close() error { return nil }
+
+func (concatAssembler) Discard() {}
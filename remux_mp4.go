@@ -0,0 +1,672 @@
+package soundcloudapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// mp4Box is a box of an ISO base media file, Data is its payload without the header
+type mp4Box struct {
+	Type string
+	Data []byte
+}
+
+// parseMP4Boxes parses the boxes contained in data
+func parseMP4Boxes(data []byte) ([]mp4Box, error) {
+	boxes := []mp4Box{}
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, errors.New("Truncated MP4 box")
+		}
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		typ := string(data[4:8])
+		headerSize := uint64(8)
+		if size == 1 {
+			if len(data) < 16 {
+				return nil, errors.New("Truncated MP4 box")
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		} else if size == 0 {
+			size = uint64(len(data))
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return nil, errors.Errorf("Invalid size of MP4 box %q", typ)
+		}
+
+		boxes = append(boxes, mp4Box{Type: typ, Data: data[headerSize:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+func findMP4Box(boxes []mp4Box, typ string) *mp4Box {
+	for i := range boxes {
+		if boxes[i].Type == typ {
+			return &boxes[i]
+		}
+	}
+	return nil
+}
+
+// mp4BoxHeader returns the header of a box with the given payload size
+func mp4BoxHeader(typ string, payloadSize uint64) []byte {
+	if payloadSize+8 > 0xffffffff {
+		header := make([]byte, 16)
+		binary.BigEndian.PutUint32(header[0:4], 1)
+		copy(header[4:8], typ)
+		binary.BigEndian.PutUint64(header[8:16], payloadSize+16)
+		return header
+	}
+
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[0:4], uint32(payloadSize+8))
+	copy(header[4:8], typ)
+	return header
+}
+
+func writeMP4Box(w *bytes.Buffer, typ string, payload []byte) {
+	w.Write(mp4BoxHeader(typ, uint64(len(payload))))
+	w.Write(payload)
+}
+
+func writeMP4Boxes(w *bytes.Buffer, typ string, children []mp4Box) {
+	payload := &bytes.Buffer{}
+	for _, child := range children {
+		writeMP4Box(payload, child.Type, child.Data)
+	}
+	writeMP4Box(w, typ, payload.Bytes())
+}
+
+// mp4Sample is the location of a sample in the spooled fragmented MP4 file
+type mp4Sample struct {
+	Offset   int64
+	Size     uint32
+	Duration uint32
+}
+
+// m4aAssembler remuxes fragmented MP4 segments into an M4A file. The segments are spooled to a temporary
+// file, and when every segment was written their samples are copied into a single mdat box preceded
+// by a moov box indexing them, so that the file can be played and seeked before it's completely read.
+type m4aAssembler struct {
+	dst   io.Writer
+	spool *os.File
+	size  int64
+}
+
+func (a *m4aAssembler) Write(p []byte) (int, error) {
+	if a.spool == nil {
+		spool, err := ioutil.TempFile("", "soundcloud-api-*.mp4")
+		if err != nil {
+			return 0, errors.Wrap(err, "Failed to create temporary file")
+		}
+		a.spool = spool
+	}
+
+	n, err := a.spool.Write(p)
+	a.size += int64(n)
+	return n, err
+}
+
+func (a *m4aAssembler) Close() error {
+	if a.spool == nil {
+		return nil
+	}
+	defer a.Discard()
+
+	return remuxMP4(a.spool, a.size, a.dst)
+}
+
+func (a *m4aAssembler) Discard() {
+	if a.spool != nil {
+		a.spool.Close()
+		os.Remove(a.spool.Name())
+		a.spool = nil
+	}
+}
+
+// mp4Defaults are the default sample values of a track or track fragment
+type mp4Defaults struct {
+	Duration uint32
+	Size     uint32
+}
+
+// remuxMP4 remuxes the fragmented MP4 file in r to dst
+func remuxMP4(r io.ReaderAt, size int64, dst io.Writer) error {
+	var moov []mp4Box
+	var moovData []byte
+	var defaults mp4Defaults
+	samples := []mp4Sample{}
+	mdats := []mp4Range{}
+
+	for offset := int64(0); offset < size; {
+		header := make([]byte, 16)
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return errors.Wrap(err, "Failed to read MP4 box")
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		typ := string(header[4:8])
+		headerSize := int64(8)
+		if boxSize == 1 {
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return errors.Wrap(err, "Failed to read MP4 box")
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		} else if boxSize == 0 {
+			boxSize = size - offset
+		}
+		if boxSize < headerSize || offset+boxSize > size {
+			return errors.Errorf("Invalid size of MP4 box %q", typ)
+		}
+
+		switch typ {
+		case "mdat":
+			mdats = append(mdats, mp4Range{Start: offset + headerSize, End: offset + boxSize})
+		case "moov", "moof":
+			data := make([]byte, boxSize-headerSize)
+			if _, err := r.ReadAt(data, offset+headerSize); err != nil {
+				return errors.Wrap(err, "Failed to read MP4 box")
+			}
+			children, err := parseMP4Boxes(data)
+			if err != nil {
+				return err
+			}
+
+			if typ == "moov" {
+				if moov != nil {
+					// The initialization segment may be repeated, but the samples are all indexed
+					// with the first one's defaults and timescale, so it can't change
+					if !bytes.Equal(data, moovData) {
+						return errors.New("MP4 initialization segment changed, which isn't supported")
+					}
+					break
+				}
+				moov, moovData = children, data
+				defaults, err = parseTrex(moov)
+			} else {
+				if moov == nil {
+					return errors.New("MP4 fragment before the initialization segment")
+				}
+				samples, err = appendFragmentSamples(samples, children, offset, defaults)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		offset += boxSize
+	}
+
+	if moov == nil {
+		return errors.New("MP4 file has no moov box")
+	}
+	if err := checkSampleBounds(samples, mdats); err != nil {
+		return err
+	}
+
+	ftyp := &bytes.Buffer{}
+	writeMP4Box(ftyp, "ftyp", []byte("M4A \x00\x00\x02\x00M4A mp42isom"))
+
+	var mdatSize uint64
+	for _, sample := range samples {
+		mdatSize += uint64(sample.Size)
+	}
+	mdatHeader := mp4BoxHeader("mdat", mdatSize)
+
+	// The size of the moov box doesn't depend on the chunk offset, so it can be built once to learn its size
+	newMoov, err := buildMoov(moov, samples, 0)
+	if err != nil {
+		return err
+	}
+	chunkOffset := uint64(ftyp.Len()+len(newMoov)) + uint64(len(mdatHeader))
+	newMoov, err = buildMoov(moov, samples, chunkOffset)
+	if err != nil {
+		return err
+	}
+
+	for _, data := range [][]byte{ftyp.Bytes(), newMoov, mdatHeader} {
+		if _, err := dst.Write(data); err != nil {
+			return err
+		}
+	}
+
+	for _, sample := range samples {
+		n, err := io.Copy(dst, io.NewSectionReader(r, sample.Offset, int64(sample.Size)))
+		if err != nil {
+			return err
+		}
+		if n < int64(sample.Size) {
+			return io.ErrUnexpectedEOF
+		}
+	}
+
+	return nil
+}
+
+// mp4Range is the range of bytes [Start, End) of a box's payload in the spooled file
+type mp4Range struct {
+	Start int64
+	End   int64
+}
+
+// checkSampleBounds checks that every sample is inside an mdat box, the offsets and sizes in trun
+// boxes could otherwise point at other boxes or past the end of the file
+func checkSampleBounds(samples []mp4Sample, mdats []mp4Range) error {
+	for _, sample := range samples {
+		if sample.Size == 0 {
+			continue
+		}
+		// The mdat boxes are in file order, so the first one ending after the sample's offset is the only one that can hold it
+		i := sort.Search(len(mdats), func(i int) bool { return mdats[i].End > sample.Offset })
+		if i == len(mdats) || sample.Offset < mdats[i].Start || sample.Offset+int64(sample.Size) > mdats[i].End {
+			return errors.Errorf("MP4 sample at offset %d with size %d is outside of the mdat boxes", sample.Offset, sample.Size)
+		}
+	}
+	return nil
+}
+
+// parseTrex returns the default sample values of the track in the mvex box of moov
+func parseTrex(moov []mp4Box) (mp4Defaults, error) {
+	defaults := mp4Defaults{}
+	mvex := findMP4Box(moov, "mvex")
+	if mvex == nil {
+		return defaults, nil
+	}
+	children, err := parseMP4Boxes(mvex.Data)
+	if err != nil {
+		return defaults, err
+	}
+	trex := findMP4Box(children, "trex")
+	if trex == nil || len(trex.Data) < 24 {
+		return defaults, nil
+	}
+
+	// version and flags, track_ID, default_sample_description_index, default_sample_duration, default_sample_size
+	defaults.Duration = binary.BigEndian.Uint32(trex.Data[12:16])
+	defaults.Size = binary.BigEndian.Uint32(trex.Data[16:20])
+	return defaults, nil
+}
+
+// Flags of tfhd boxes
+const (
+	tfhdBaseDataOffset         = 0x1
+	tfhdSampleDescriptionIndex = 0x2
+	tfhdDefaultSampleDuration  = 0x8
+	tfhdDefaultSampleSize      = 0x10
+)
+
+// Flags of trun boxes
+const (
+	trunDataOffset                  = 0x1
+	trunFirstSampleFlags            = 0x4
+	trunSampleDuration              = 0x100
+	trunSampleSize                  = 0x200
+	trunSampleFlags                 = 0x400
+	trunSampleCompositionTimeOffset = 0x800
+)
+
+// appendFragmentSamples appends the samples of the moof box at moofOffset to samples
+func appendFragmentSamples(samples []mp4Sample, moof []mp4Box, moofOffset int64, trackDefaults mp4Defaults) ([]mp4Sample, error) {
+	for _, traf := range moof {
+		if traf.Type != "traf" {
+			continue
+		}
+		children, err := parseMP4Boxes(traf.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		tfhd := findMP4Box(children, "tfhd")
+		if tfhd == nil || len(tfhd.Data) < 8 {
+			return nil, errors.New("MP4 fragment has no tfhd box")
+		}
+		defaults := trackDefaults
+		base := moofOffset
+		flags := binary.BigEndian.Uint32(tfhd.Data[0:4]) & 0xffffff
+		fields := tfhd.Data[8:]
+		next := func() (uint32, error) {
+			if len(fields) < 4 {
+				return 0, errors.New("Truncated tfhd box")
+			}
+			v := binary.BigEndian.Uint32(fields[:4])
+			fields = fields[4:]
+			return v, nil
+		}
+		if flags&tfhdBaseDataOffset != 0 {
+			high, err := next()
+			if err != nil {
+				return nil, err
+			}
+			low, err := next()
+			if err != nil {
+				return nil, err
+			}
+			base = int64(high)<<32 | int64(low)
+		}
+		if flags&tfhdSampleDescriptionIndex != 0 {
+			if _, err := next(); err != nil {
+				return nil, err
+			}
+		}
+		if flags&tfhdDefaultSampleDuration != 0 {
+			if defaults.Duration, err = next(); err != nil {
+				return nil, err
+			}
+		}
+		if flags&tfhdDefaultSampleSize != 0 {
+			if defaults.Size, err = next(); err != nil {
+				return nil, err
+			}
+		}
+
+		// Without a data offset, the samples of a trun follow the previous trun's
+		offset := base
+		for _, trun := range children {
+			if trun.Type != "trun" {
+				continue
+			}
+			samples, offset, err = appendTrunSamples(samples, trun.Data, base, offset, defaults)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return samples, nil
+}
+
+func appendTrunSamples(samples []mp4Sample, trun []byte, base int64, offset int64, defaults mp4Defaults) ([]mp4Sample, int64, error) {
+	if len(trun) < 8 {
+		return nil, 0, errors.New("Truncated trun box")
+	}
+	flags := binary.BigEndian.Uint32(trun[0:4]) & 0xffffff
+	count := binary.BigEndian.Uint32(trun[4:8])
+	data := trun[8:]
+
+	if flags&trunDataOffset != 0 {
+		if len(data) < 4 {
+			return nil, 0, errors.New("Truncated trun box")
+		}
+		offset = base + int64(int32(binary.BigEndian.Uint32(data[:4])))
+		data = data[4:]
+	}
+	if flags&trunFirstSampleFlags != 0 {
+		if len(data) < 4 {
+			return nil, 0, errors.New("Truncated trun box")
+		}
+		data = data[4:]
+	}
+
+	fieldSize := 0
+	for _, flag := range []uint32{trunSampleDuration, trunSampleSize, trunSampleFlags, trunSampleCompositionTimeOffset} {
+		if flags&flag != 0 {
+			fieldSize += 4
+		}
+	}
+	if uint64(len(data)) < uint64(count)*uint64(fieldSize) {
+		return nil, 0, errors.New("Truncated trun box")
+	}
+
+	for i := uint32(0); i < count; i++ {
+		sample := mp4Sample{Offset: offset, Duration: defaults.Duration, Size: defaults.Size}
+		if flags&trunSampleDuration != 0 {
+			sample.Duration = binary.BigEndian.Uint32(data[:4])
+			data = data[4:]
+		}
+		if flags&trunSampleSize != 0 {
+			sample.Size = binary.BigEndian.Uint32(data[:4])
+			data = data[4:]
+		}
+		if flags&trunSampleFlags != 0 {
+			data = data[4:]
+		}
+		if flags&trunSampleCompositionTimeOffset != 0 {
+			data = data[4:]
+		}
+
+		samples = append(samples, sample)
+		offset += int64(sample.Size)
+	}
+
+	return samples, offset, nil
+}
+
+// buildMoov returns the moov box of the remuxed file, in which every sample is in one chunk at chunkOffset
+func buildMoov(moov []mp4Box, samples []mp4Sample, chunkOffset uint64) ([]byte, error) {
+	var mediaDuration uint64
+	for _, sample := range samples {
+		mediaDuration += uint64(sample.Duration)
+	}
+
+	if n := len(findAllMP4Boxes(moov, "trak")); n != 1 {
+		return nil, errors.Errorf("Expected MP4 file with 1 track, found %d tracks", n)
+	}
+
+	mvhd := findMP4Box(moov, "mvhd")
+	if mvhd == nil {
+		return nil, errors.New("MP4 file has no mvhd box")
+	}
+	movieTimescale, err := mp4Timescale(mvhd.Data)
+	if err != nil {
+		return nil, err
+	}
+	mvhdData := append([]byte{}, mvhd.Data...)
+
+	newMoov := []mp4Box{}
+	for _, box := range moov {
+		switch box.Type {
+		case "mvex":
+			// The remuxed file isn't fragmented
+			continue
+		case "mvhd":
+			box = mp4Box{Type: "mvhd", Data: mvhdData}
+		case "trak":
+			trak, mediaTimescale, err := buildTrak(box.Data, samples, mediaDuration, chunkOffset)
+			if err != nil {
+				return nil, err
+			}
+			movieDuration := mediaDuration * uint64(movieTimescale) / uint64(mediaTimescale)
+			if err := setMP4Duration("tkhd", trak[0].Data, movieDuration); err != nil {
+				return nil, err
+			}
+			if err := setMP4Duration("mvhd", mvhdData, movieDuration); err != nil {
+				return nil, err
+			}
+			payload := &bytes.Buffer{}
+			for _, child := range trak {
+				writeMP4Box(payload, child.Type, child.Data)
+			}
+			box = mp4Box{Type: "trak", Data: payload.Bytes()}
+		}
+		newMoov = append(newMoov, box)
+	}
+
+	w := &bytes.Buffer{}
+	writeMP4Boxes(w, "moov", newMoov)
+	return w.Bytes(), nil
+}
+
+func findAllMP4Boxes(boxes []mp4Box, typ string) []mp4Box {
+	found := []mp4Box{}
+	for _, box := range boxes {
+		if box.Type == typ {
+			found = append(found, box)
+		}
+	}
+	return found
+}
+
+// buildTrak returns the children of the remuxed trak box, with tkhd first, and the media timescale
+func buildTrak(data []byte, samples []mp4Sample, mediaDuration uint64, chunkOffset uint64) ([]mp4Box, uint32, error) {
+	trak, err := parseMP4Boxes(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tkhd := findMP4Box(trak, "tkhd")
+	mdia := findMP4Box(trak, "mdia")
+	if tkhd == nil || mdia == nil {
+		return nil, 0, errors.New("MP4 track has no tkhd or mdia box")
+	}
+	mdiaChildren, err := parseMP4Boxes(mdia.Data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	mdhd := findMP4Box(mdiaChildren, "mdhd")
+	minf := findMP4Box(mdiaChildren, "minf")
+	if mdhd == nil || minf == nil {
+		return nil, 0, errors.New("MP4 track has no mdhd or minf box")
+	}
+	mediaTimescale, err := mp4Timescale(mdhd.Data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if mediaTimescale == 0 {
+		return nil, 0, errors.New("MP4 track has no timescale")
+	}
+	mdhd.Data = append([]byte{}, mdhd.Data...)
+	if err := setMP4Duration("mdhd", mdhd.Data, mediaDuration); err != nil {
+		return nil, 0, err
+	}
+
+	minfChildren, err := parseMP4Boxes(minf.Data)
+	if err != nil {
+		return nil, 0, err
+	}
+	stbl := findMP4Box(minfChildren, "stbl")
+	if stbl == nil {
+		return nil, 0, errors.New("MP4 track has no stbl box")
+	}
+	stblChildren, err := parseMP4Boxes(stbl.Data)
+	if err != nil {
+		return nil, 0, err
+	}
+	stsd := findMP4Box(stblChildren, "stsd")
+	if stsd == nil {
+		return nil, 0, errors.New("MP4 track has no stsd box")
+	}
+
+	newStbl := &bytes.Buffer{}
+	writeMP4Box(newStbl, "stsd", stsd.Data)
+	writeMP4Box(newStbl, "stts", buildStts(samples))
+	writeMP4Box(newStbl, "stsc", buildStsc(samples))
+	writeMP4Box(newStbl, "stsz", buildStsz(samples))
+	writeMP4Box(newStbl, "stco", buildStco(samples, chunkOffset))
+	stbl.Data = newStbl.Bytes()
+
+	minf.Data = encodeMP4Boxes(minfChildren)
+	mdia.Data = encodeMP4Boxes(mdiaChildren)
+
+	// tkhd is returned first so that its duration can be set
+	newTrak := []mp4Box{{Type: "tkhd", Data: append([]byte{}, tkhd.Data...)}}
+	for _, box := range trak {
+		if box.Type != "tkhd" {
+			newTrak = append(newTrak, box)
+		}
+	}
+
+	return newTrak, mediaTimescale, nil
+}
+
+func encodeMP4Boxes(boxes []mp4Box) []byte {
+	w := &bytes.Buffer{}
+	for _, box := range boxes {
+		writeMP4Box(w, box.Type, box.Data)
+	}
+	return w.Bytes()
+}
+
+// buildStts returns the payload of an stts box, which run-length encodes the durations of the samples
+func buildStts(samples []mp4Sample) []byte {
+	entries := [][2]uint32{}
+	for _, sample := range samples {
+		if n := len(entries); n > 0 && entries[n-1][1] == sample.Duration {
+			entries[n-1][0]++
+			continue
+		}
+		entries = append(entries, [2]uint32{1, sample.Duration})
+	}
+
+	data := make([]byte, 8, 8+8*len(entries))
+	binary.BigEndian.PutUint32(data[4:8], uint32(len(entries)))
+	for _, entry := range entries {
+		data = appendUint32(data, entry[0], entry[1])
+	}
+	return data
+}
+
+// buildStsc returns the payload of an stsc box that puts every sample in the first chunk
+func buildStsc(samples []mp4Sample) []byte {
+	if len(samples) == 0 {
+		return make([]byte, 8)
+	}
+	return appendUint32(make([]byte, 4), 1, 1, uint32(len(samples)), 1)
+}
+
+func buildStsz(samples []mp4Sample) []byte {
+	data := appendUint32(make([]byte, 4), 0, uint32(len(samples)))
+	for _, sample := range samples {
+		data = appendUint32(data, sample.Size)
+	}
+	return data
+}
+
+func buildStco(samples []mp4Sample, chunkOffset uint64) []byte {
+	if len(samples) == 0 {
+		return make([]byte, 8)
+	}
+	return appendUint32(make([]byte, 4), 1, uint32(chunkOffset))
+}
+
+func appendUint32(data []byte, values ...uint32) []byte {
+	for _, v := range values {
+		data = append(data, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+	return data
+}
+
+// mp4Timescale returns the timescale of an mvhd or mdhd box's payload
+func mp4Timescale(data []byte) (uint32, error) {
+	offset := 12
+	if len(data) > 0 && data[0] == 1 {
+		offset = 20
+	}
+	if len(data) < offset+4 {
+		return 0, errors.New("Truncated MP4 header box")
+	}
+	return binary.BigEndian.Uint32(data[offset : offset+4]), nil
+}
+
+// setMP4Duration sets the duration of an mvhd, tkhd or mdhd box's payload
+func setMP4Duration(typ string, data []byte, duration uint64) error {
+	offset := 16
+	if typ == "tkhd" {
+		offset = 20
+	}
+	size := 4
+	if len(data) > 0 && data[0] == 1 {
+		offset += 8
+		size = 8
+	}
+	if len(data) < offset+size {
+		return errors.Errorf("Truncated %s box", typ)
+	}
+
+	if size == 8 {
+		binary.BigEndian.PutUint64(data[offset:], duration)
+	} else {
+		if duration > 0xffffffff {
+			duration = 0xffffffff
+		}
+		binary.BigEndian.PutUint32(data[offset:], uint32(duration))
+	}
+	return nil
+}
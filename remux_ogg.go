@@ -0,0 +1,218 @@
+package soundcloudapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Header type flags of an Ogg page
+const (
+	oggContinued = 0x01
+	oggBOS       = 0x02
+	oggEOS       = 0x04
+)
+
+// oggHeaderSize is the size of an Ogg page header without the lacing values
+const oggHeaderSize = 27
+
+type oggPage struct {
+	HeaderType byte
+	Granule    int64 // -1 if no packet finishes on the page
+	Serial     uint32
+	Sequence   uint32
+	Lacing     []byte
+	Body       []byte
+}
+
+// parseOggPage parses the page at the start of data, and returns its size. 0 is returned if data
+// doesn't contain a whole page yet.
+func parseOggPage(data []byte) (*oggPage, int, error) {
+	if len(data) < oggHeaderSize {
+		return nil, 0, nil
+	}
+	if !bytes.HasPrefix(data, []byte("OggS")) || data[4] != 0 {
+		return nil, 0, errors.New("Invalid Ogg page")
+	}
+
+	segments := int(data[26])
+	if len(data) < oggHeaderSize+segments {
+		return nil, 0, nil
+	}
+	lacing := data[oggHeaderSize : oggHeaderSize+segments]
+	bodySize := 0
+	for _, l := range lacing {
+		bodySize += int(l)
+	}
+	size := oggHeaderSize + segments + bodySize
+	if len(data) < size {
+		return nil, 0, nil
+	}
+
+	return &oggPage{
+		HeaderType: data[5],
+		Granule:    int64(binary.LittleEndian.Uint64(data[6:14])),
+		Serial:     binary.LittleEndian.Uint32(data[14:18]),
+		Sequence:   binary.LittleEndian.Uint32(data[18:22]),
+		Lacing:     append([]byte{}, lacing...),
+		Body:       append([]byte{}, data[oggHeaderSize+segments:size]...),
+	}, size, nil
+}
+
+// startsPacket returns true if a packet starting with prefix starts at the beginning of the page
+func (p *oggPage) startsPacket(prefix string) bool {
+	return p.HeaderType&oggContinued == 0 && bytes.HasPrefix(p.Body, []byte(prefix))
+}
+
+// Bytes encodes the page with its checksum
+func (p *oggPage) Bytes() []byte {
+	data := make([]byte, oggHeaderSize, oggHeaderSize+len(p.Lacing)+len(p.Body))
+	copy(data, "OggS")
+	data[5] = p.HeaderType
+	binary.LittleEndian.PutUint64(data[6:14], uint64(p.Granule))
+	binary.LittleEndian.PutUint32(data[14:18], p.Serial)
+	binary.LittleEndian.PutUint32(data[18:22], p.Sequence)
+	data[26] = byte(len(p.Lacing))
+	data = append(data, p.Lacing...)
+	data = append(data, p.Body...)
+	binary.LittleEndian.PutUint32(data[22:26], oggCRC(data))
+	return data
+}
+
+var oggCRCTable = func() [256]uint32 {
+	table := [256]uint32{}
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+// oggCRC computes the checksum of a page whose checksum field is 0
+func oggCRC(data []byte) uint32 {
+	crc := uint32(0)
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// oggAssembler rewrites the pages of Ogg Opus segments into one valid Ogg stream.
+//
+// Each segment may be a whole Ogg stream with its own headers, serial number, page sequence numbers and
+// granule positions. Only the headers of the first stream are kept, and the pages of every stream are
+// renumbered and given the first stream's serial number and granule positions following the previous stream's.
+type oggAssembler struct {
+	dst io.Writer
+	buf []byte
+
+	started        bool
+	serial         uint32
+	sequence       uint32
+	base           int64 // added to the granule positions of the current stream
+	last           int64 // granule position of the last page written
+	skippingHeader bool  // whether the headers of a stream after the first one are being skipped
+	pending        *oggPage
+}
+
+func (a *oggAssembler) Write(p []byte) (int, error) {
+	a.buf = append(a.buf, p...)
+
+	for {
+		page, size, err := parseOggPage(a.buf)
+		if err != nil {
+			return 0, err
+		}
+		if size == 0 {
+			break
+		}
+		a.buf = a.buf[size:]
+
+		if err := a.rewrite(page); err != nil {
+			return 0, err
+		}
+	}
+
+	// Don't keep the parsed pages' memory alive
+	a.buf = append([]byte{}, a.buf...)
+	return len(p), nil
+}
+
+func (a *oggAssembler) rewrite(page *oggPage) error {
+	if !a.started {
+		if page.HeaderType&oggBOS == 0 || !page.startsPacket("OpusHead") {
+			return errors.New("Ogg stream doesn't start with an OpusHead page")
+		}
+		a.started = true
+		a.serial = page.Serial
+		return a.write(page)
+	}
+
+	if page.HeaderType&oggBOS != 0 {
+		// A new stream starts, its headers are the same as the first stream's
+		a.base = a.last
+		a.skippingHeader = true
+		return nil
+	}
+
+	if a.skippingHeader {
+		if page.startsPacket("OpusTags") || page.HeaderType&oggContinued != 0 {
+			return nil
+		}
+		a.skippingHeader = false
+	}
+
+	if page.Granule != -1 {
+		page.Granule += a.base
+		a.last = page.Granule
+	}
+
+	return a.write(page)
+}
+
+// write writes the page before the given one, since only the last page may have the EOS flag
+func (a *oggAssembler) write(page *oggPage) error {
+	page.Serial = a.serial
+	page.Sequence = a.sequence
+	a.sequence++
+	page.HeaderType &^= oggEOS
+	if page.Sequence != 0 {
+		page.HeaderType &^= oggBOS
+	}
+
+	previous := a.pending
+	a.pending = page
+	if previous == nil {
+		return nil
+	}
+	_, err := a.dst.Write(previous.Bytes())
+	return err
+}
+
+func (a *oggAssembler) Close() error {
+	if len(a.buf) > 0 {
+		return errors.New("Ogg stream ends with a truncated page")
+	}
+	if a.pending == nil {
+		return nil
+	}
+
+	a.pending.HeaderType |= oggEOS
+	_, err := a.dst.Write(a.pending.Bytes())
+	a.pending = nil
+	return err
+}
+
+func (a *oggAssembler) Discard() {
+	a.buf = nil
+	a.pending = nil
+}
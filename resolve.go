@@ -0,0 +1,89 @@
+package soundcloudapi
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// Resource is a resource returned by Resolve. Only the field of its Kind is set.
+type Resource struct {
+	Kind     string // "track", "playlist" or "user"
+	Track    *Track
+	Playlist *Playlist // like the resolve endpoint, only the first 5 tracks have full info, see GetPlaylistInfo
+	User     *User
+}
+
+// ID returns the ID of the resource
+func (r Resource) ID() int64 {
+	switch {
+	case r.Track != nil:
+		return r.Track.ID
+	case r.Playlist != nil:
+		return r.Playlist.ID
+	case r.User != nil:
+		return r.User.ID
+	}
+	return 0
+}
+
+// PermalinkURL returns the permalink URL of the resource
+func (r Resource) PermalinkURL() string {
+	switch {
+	case r.Track != nil:
+		return r.Track.PermalinkURL
+	case r.Playlist != nil:
+		return r.Playlist.PermalinkURL
+	case r.User != nil:
+		return r.User.PermalinkURL
+	}
+	return ""
+}
+
+// Resolve returns the track, playlist or user at a SoundCloud URL
+func (sc *API) Resolve(url string) (Resource, error) {
+	return sc.ResolveContext(context.Background(), url)
+}
+
+// ResolveContext is like Resolve but with a context
+func (sc *API) ResolveContext(ctx context.Context, url string) (Resource, error) {
+	resource := Resource{}
+	url, err := sc.prepareURL(ctx, url)
+	if err != nil {
+		return resource, err
+	}
+
+	data, err := sc.client.resolve(ctx, url)
+	if err != nil {
+		return resource, err
+	}
+
+	kind := struct {
+		Kind string `json:"kind"`
+	}{}
+	if err := json.Unmarshal(data, &kind); err != nil {
+		return resource, errors.Wrap(err, "Returned JSON is not a valid resource")
+	}
+
+	resource.Kind = kind.Kind
+	var v interface{}
+	switch kind.Kind {
+	case "track":
+		resource.Track = &Track{}
+		v = resource.Track
+	case "playlist":
+		resource.Playlist = &Playlist{}
+		v = resource.Playlist
+	case "user":
+		resource.User = &User{}
+		v = resource.User
+	default:
+		return resource, errors.Errorf("Resolved resource has an unknown kind (%s)", kind.Kind)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return resource, errors.Wrapf(err, "Returned JSON is not valid %s info", kind.Kind)
+	}
+	return resource, nil
+}
@@ -0,0 +1,162 @@
+package soundcloudapi
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultRetryableStatuses are the response statuses retried by a RetryPolicy with no RetryableStatuses
+var DefaultRetryableStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy configures how requests that failed with a transient error are retried.
+// Only idempotent requests (API GET requests and media downloads) are retried.
+// The zero value disables retries.
+type RetryPolicy struct {
+	MaxAttempts        int           // maximum number of attempts including the first one, 0 or 1 disables retries
+	BaseDelay          time.Duration // delay before the first retry, doubled for each subsequent retry, defaults to 500ms
+	MaxDelay           time.Duration // maximum delay between two attempts, including delays requested by Retry-After, defaults to 30s
+	Jitter             float64       // fraction between 0 and 1 of each delay that is randomized
+	RetryableStatuses  []int         // response statuses that are retried, defaults to DefaultRetryableStatuses
+	RetryNetworkErrors bool          // whether or not to retry connection resets, timeouts and other network errors
+}
+
+// DefaultRetryPolicy returns a RetryPolicy that makes up to 4 attempts
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:        4,
+		BaseDelay:          500 * time.Millisecond,
+		MaxDelay:           30 * time.Second,
+		Jitter:             0.2,
+		RetryNetworkErrors: true,
+	}
+}
+
+// retryable returns true if err is a transient error that p retries
+func (p RetryPolicy) retryable(err error) bool {
+	if failedRequest, ok := err.(*FailedRequestError); ok {
+		statuses := p.RetryableStatuses
+		if statuses == nil {
+			statuses = DefaultRetryableStatuses
+		}
+		for _, status := range statuses {
+			if failedRequest.Status == status {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !p.RetryNetworkErrors || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// Failures to dial, read from or write to a connection
+	var opErr *net.OpError
+	return errors.As(err, &opErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// delay returns how long to wait before the attempt after the given attempt that failed with err
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	max := p.MaxDelay
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+
+	if p.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(delay))
+	}
+
+	if failedRequest, ok := err.(*FailedRequestError); ok && failedRequest.RetryAfter > delay {
+		delay = failedRequest.RetryAfter
+	}
+
+	if delay > max {
+		delay = max
+	}
+
+	return delay
+}
+
+// withRetry calls attempt until it succeeds, fails with an error that isn't retryable,
+// or c.retry.MaxAttempts attempts were made. In a context returned by withoutRetry it's only attempted once.
+func (c *client) withRetry(ctx context.Context, attempt func() error) error {
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil || n >= c.retry.MaxAttempts || !c.retry.retryable(err) || ctx.Value(noRetryKey{}) != nil {
+			return err
+		}
+
+		timer := time.NewTimer(c.retry.delay(n, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// noRetryKey is the context key set by withoutRetry
+type noRetryKey struct{}
+
+// withoutRetry returns a context in which withRetry makes a single attempt. Requests made by an attempt
+// of withRetry use it, otherwise their retries would multiply with the attempts.
+func withoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either
+// an amount of seconds or an HTTP date. 0 is returned if the value is invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
@@ -1,6 +1,7 @@
 package soundcloudapi
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"strings"
@@ -13,22 +14,52 @@ type API struct {
 	client              *client
 	StripMobilePrefix   bool
 	ConvertFirebaseURLs bool
+	AllowPreviews       bool
 }
 
 // APIOptions are the options for creating an API struct
 type APIOptions struct {
-	ClientID            string       // optional and a new one will be fetched if not provided
-	HTTPClient          *http.Client // the HTTP client to make requests with
-	StripMobilePrefix   bool         // whether or not to convert mobile URLs to regular URLs
-	ConvertFirebaseURLs bool         // whether or not to convert SoundCloud firebase URLs to regular URLs
+	ClientID            string           // optional and a new one will be fetched from ClientIDProvider if not provided
+	ClientIDProvider    ClientIDProvider // provides client IDs, defaults to a ScrapingClientIDProvider
+	HTTPClient          *http.Client     // the HTTP client to make requests with
+	StripMobilePrefix   bool             // whether or not to convert mobile URLs to regular URLs
+	ConvertFirebaseURLs bool             // whether or not to convert SoundCloud firebase URLs to regular URLs
+	APIBaseURL          string           // base URL of api-v2, defaults to DefaultAPIBaseURL
+	WebBaseURL          string           // base URL of the web app scraped for a client ID, defaults to DefaultWebBaseURL
+	AssetBaseURL        string           // base URL of the web app's JS assets, defaults to DefaultAssetBaseURL
+	AutoRefreshClientID bool             // whether or not to fetch a new client ID and retry when SoundCloud rejects the current one
+	RetryPolicy         RetryPolicy      // how to retry requests that failed with a transient error, the zero value disables retries
+	APIRateLimiter      *RateLimiter     // limits requests to api-v2, can be shared with other APIs, nil doesn't limit requests
+	MediaRateLimiter    *RateLimiter     // limits requests to the media CDN, can be shared with other APIs, nil doesn't limit requests
+	HLSWorkers          int              // number of HLS segments downloaded concurrently, defaults to DefaultHLSWorkers
+	HLSWindow           int              // maximum number of HLS segments downloading or buffered in memory, defaults to 2 * HLSWorkers
+	HLSVariant          VariantPolicy    // which variant of HLS master playlists to download, defaults to the highest bandwidth
+	AllowPreviews       bool             // whether or not to download snipped previews instead of returning a *PreviewOnlyError, when downloading without a TranscodingPolicy
 }
 
 // New returns a pointer to a new SoundCloud API struct.
 func New(options APIOptions) (*API, error) {
 
+	if options.WebBaseURL == "" {
+		options.WebBaseURL = DefaultWebBaseURL
+	}
+
+	if options.AssetBaseURL == "" {
+		options.AssetBaseURL = DefaultAssetBaseURL
+	}
+
+	if options.ClientIDProvider == nil {
+		options.ClientIDProvider = &ScrapingClientIDProvider{
+			HTTPClient:   options.HTTPClient,
+			WebBaseURL:   options.WebBaseURL,
+			AssetBaseURL: options.AssetBaseURL,
+			APIBaseURL:   options.APIBaseURL,
+		}
+	}
+
 	if options.ClientID == "" {
 		var err error
-		options.ClientID, err = FetchClientID()
+		options.ClientID, err = options.ClientIDProvider.ClientID(context.Background())
 		if err != nil {
 			return nil, errors.Wrap(err, "Failed to initiaze SounCloudAPI")
 		}
@@ -38,21 +69,40 @@ func New(options APIOptions) (*API, error) {
 		options.HTTPClient = http.DefaultClient
 	}
 
+	c := newClient(options.ClientID, options.HTTPClient, options.APIBaseURL, options.RetryPolicy)
+	c.apiLimiter = options.APIRateLimiter
+	c.mediaLimiter = options.MediaRateLimiter
+	c.hlsWorkers = options.HLSWorkers
+	c.hlsWindow = options.HLSWindow
+	c.hlsVariant = options.HLSVariant
+	if options.AutoRefreshClientID {
+		provider := options.ClientIDProvider
+		c.refreshClientID = func(ctx context.Context, stale string) (string, error) {
+			if invalidator, ok := provider.(ClientIDInvalidator); ok {
+				if err := invalidator.InvalidateClientID(ctx, stale); err != nil {
+					return "", err
+				}
+			}
+			return provider.ClientID(ctx)
+		}
+	}
+
 	return &API{
-		client:              newClient(options.ClientID, options.HTTPClient),
+		client:              c,
 		StripMobilePrefix:   options.StripMobilePrefix,
 		ConvertFirebaseURLs: options.ConvertFirebaseURLs,
+		AllowPreviews:       options.AllowPreviews,
 	}, nil
 }
 
 // SetClientID sets the client ID
 func (sc *API) SetClientID(clientID string) {
-	sc.client.clientID = clientID
+	sc.client.setClientID(clientID)
 }
 
 // ClientID returns the client ID
 func (sc *API) ClientID() string {
-	return sc.client.clientID
+	return sc.client.getClientID()
 }
 
 // GetTrackInfo returns the info for the track given tracks
@@ -63,41 +113,95 @@ func (sc *API) ClientID() string {
 // WARNING: Private tracks will not be fetched unless options.PlaylistID and options.PlaylistSecretToken
 // are provided.
 func (sc *API) GetTrackInfo(options GetTrackInfoOptions) ([]Track, error) {
+	return sc.GetTrackInfoContext(context.Background(), options)
+}
+
+// GetTrackInfoContext is like GetTrackInfo but with a context
+func (sc *API) GetTrackInfoContext(ctx context.Context, options GetTrackInfoOptions) ([]Track, error) {
 	if options.URL != "" {
-		url, err := sc.prepareURL(options.URL)
+		url, err := sc.prepareURL(ctx, options.URL)
 		if err != nil {
 			return nil, err
 		}
 		options.URL = url
 		id := ExtractIDFromPersonalizedTrackURL(options.URL)
 		if id != -1 {
-			return sc.client.getTrackInfo(GetTrackInfoOptions{ID: []int64{id}})
+			return sc.client.getTrackInfo(ctx, GetTrackInfoOptions{ID: []int64{id}})
 		}
 	}
-	return sc.client.getTrackInfo(options)
+	return sc.client.getTrackInfo(ctx, options)
 }
 
 // GetPlaylistInfo returns the info for a playlist
 func (sc *API) GetPlaylistInfo(url string) (Playlist, error) {
-	return sc.client.getPlaylistInfo(StripMobilePrefix(url))
+	return sc.GetPlaylistInfoContext(context.Background(), url)
+}
+
+// GetPlaylistInfoContext is like GetPlaylistInfo but with a context
+func (sc *API) GetPlaylistInfoContext(ctx context.Context, url string) (Playlist, error) {
+	return sc.client.getPlaylistInfo(ctx, StripMobilePrefix(url))
 }
 
 // DownloadTrack downloads the track specified by the given Transcoding's URL to dst
 func (sc *API) DownloadTrack(transcoding Transcoding, dst io.Writer) error {
-	url, err := sc.prepareURL(transcoding.URL)
+	return sc.DownloadTrackContext(context.Background(), transcoding, dst)
+}
+
+// DownloadTrackContext is like DownloadTrack but with a context
+func (sc *API) DownloadTrackContext(ctx context.Context, transcoding Transcoding, dst io.Writer) error {
+	return sc.DownloadTrackWithOptions(ctx, transcoding, dst, DownloadOptions{})
+}
+
+// DownloadOptions are the options for downloading a track
+type DownloadOptions struct {
+	// Progress is called every time data is written to dst, from the goroutine that started the download
+	Progress ProgressFunc
+
+	// Tag is the track being downloaded. If set and the transcoding is audio/mpeg, an ID3v2 tag with the
+	// track's metadata and artwork is written before the audio. The download fails if the artwork can't be
+	// downloaded, use NewID3Writer with NewID3Tag to tag tracks without artwork.
+	Tag *Track
+}
+
+// DownloadTrackWithOptions is like DownloadTrackContext but with DownloadOptions
+func (sc *API) DownloadTrackWithOptions(ctx context.Context, transcoding Transcoding, dst io.Writer, options DownloadOptions) error {
+	if err := sc.checkPreview(ctx, transcoding, options.Tag); err != nil {
+		return err
+	}
+	return sc.downloadTrack(ctx, transcoding, dst, options)
+}
+
+// downloadTrack downloads transcoding to dst without checking whether it's a preview
+func (sc *API) downloadTrack(ctx context.Context, transcoding Transcoding, dst io.Writer, options DownloadOptions) error {
+	url, err := sc.prepareURL(ctx, transcoding.URL)
 	if err != nil {
 		return err
 	}
-	u, err := sc.client.getMediaURL(url)
+	u, err := sc.client.getMediaURL(ctx, url)
 	if err != nil {
 		return err
 	}
+	var id3Writer *ID3Writer
+	if options.Tag != nil && strings.HasPrefix(transcoding.Format.MimeType, "audio/mpeg") {
+		tag, err := sc.client.id3Tag(ctx, *options.Tag)
+		if err != nil {
+			return err
+		}
+		id3Writer = NewID3Writer(dst, tag)
+		dst = id3Writer
+	}
+
+	progress := newProgressReporter(options.Progress)
 	if strings.Contains(transcoding.URL, "progressive") {
 		// Progressive download
-		err = sc.client.downloadProgressive(u, dst)
+		err = sc.client.downloadProgressive(ctx, u, dst, progress)
 	} else {
 		// HLS download
-		err = sc.client.downloadHLS(u, dst)
+		err = sc.client.downloadHLS(ctx, u, transcoding.Format.MimeType, dst, progress)
+	}
+
+	if err == nil && id3Writer != nil {
+		err = id3Writer.Close()
 	}
 
 	return err
@@ -105,27 +209,42 @@ func (sc *API) DownloadTrack(transcoding Transcoding, dst io.Writer) error {
 
 // GetLikes returns a PaginatedQuery with the Collection field member as a list of tracks
 func (sc *API) GetLikes(options GetLikesOptions) (*PaginatedQuery, error) {
-	url, err := sc.prepareURL(options.ProfileURL)
+	return sc.GetLikesContext(context.Background(), options)
+}
+
+// GetLikesContext is like GetLikes but with a context
+func (sc *API) GetLikesContext(ctx context.Context, options GetLikesOptions) (*PaginatedQuery, error) {
+	url, err := sc.prepareURL(ctx, options.ProfileURL)
 	if err != nil {
 		return nil, err
 	}
 	options.ProfileURL = url
-	return sc.client.getLikes(options)
+	return sc.client.getLikes(ctx, options)
 }
 
 // Search returns a PaginatedQuery for searching a specific query
 func (sc *API) Search(options SearchOptions) (*PaginatedQuery, error) {
-	return sc.client.search(options)
+	return sc.SearchContext(context.Background(), options)
+}
+
+// SearchContext is like Search but with a context
+func (sc *API) SearchContext(ctx context.Context, options SearchOptions) (*PaginatedQuery, error) {
+	return sc.client.search(ctx, options)
 }
 
 // GetUser returns a User
 func (sc *API) GetUser(options GetUserOptions) (User, error) {
-	url, err := sc.prepareURL(options.ProfileURL)
+	return sc.GetUserContext(context.Background(), options)
+}
+
+// GetUserContext is like GetUser but with a context
+func (sc *API) GetUserContext(ctx context.Context, options GetUserOptions) (User, error) {
+	url, err := sc.prepareURL(ctx, options.ProfileURL)
 	if err != nil {
 		return User{}, err
 	}
 	options.ProfileURL = url
-	return sc.client.getUser(options)
+	return sc.client.getUser(ctx, options)
 }
 
 // GetDownloadURL retuns the URL to download a track. This is useful if you want to implement your own
@@ -133,7 +252,12 @@ func (sc *API) GetUser(options GetUserOptions) (User, error) {
 // If the track has a publicly available download link, that link will be preferred and the streamType parameter will be ignored.
 // streamType can be either "hls" or "progressive", defaults to "progressive"
 func (sc *API) GetDownloadURL(url string, streamType string) (string, error) {
-	url, err := sc.prepareURL(url)
+	return sc.GetDownloadURLContext(context.Background(), url, streamType)
+}
+
+// GetDownloadURLContext is like GetDownloadURL but with a context
+func (sc *API) GetDownloadURLContext(ctx context.Context, url string, streamType string) (string, error) {
+	url, err := sc.prepareURL(ctx, url)
 	if err != nil {
 		return "", err
 	}
@@ -143,7 +267,7 @@ func (sc *API) GetDownloadURL(url string, streamType string) (string, error) {
 	}
 
 	if IsURL(url, false, false) && !IsPlaylistURL(url) {
-		info, err := sc.client.getTrackInfo(GetTrackInfoOptions{
+		info, err := sc.client.getTrackInfo(ctx, GetTrackInfoOptions{
 			URL: url,
 		})
 
@@ -156,24 +280,23 @@ func (sc *API) GetDownloadURL(url string, streamType string) (string, error) {
 		}
 
 		if info[0].Downloadable && info[0].HasDownloadsLeft {
-			downloadURL, err := sc.client.getDownloadURL(info[0].ID)
+			downloadURL, err := sc.client.getDownloadURL(ctx, info[0].ID)
 			if err != nil {
 				return "", err
 			}
 			return downloadURL, nil
 		}
 
-		for _, transcoding := range info[0].Media.Transcodings {
-			if strings.ToLower(transcoding.Format.Protocol) == streamType {
-				mediaURL, err := sc.client.getMediaURL(transcoding.URL)
-				if err != nil {
-					return "", err
-				}
-				return mediaURL, nil
+		// Prefer the stream type but fall back to any other transcoding
+		transcoding, err := SelectTranscoding(info[0], TranscodingPolicy{Protocols: []string{streamType}, AllowSnipped: sc.AllowPreviews})
+		if err != nil {
+			if previewOnly(info[0]) {
+				return "", newPreviewOnlyError(info[0])
 			}
+			return "", err
 		}
 
-		mediaURL, err := sc.client.getMediaURL(info[0].Media.Transcodings[0].URL)
+		mediaURL, err := sc.client.getMediaURL(ctx, transcoding.URL)
 		if err != nil {
 			return "", err
 		}
@@ -182,7 +305,7 @@ func (sc *API) GetDownloadURL(url string, streamType string) (string, error) {
 	return "", errors.New("URL is not a track URL")
 }
 
-func (sc *API) prepareURL(url string) (string, error) {
+func (sc *API) prepareURL(ctx context.Context, url string) (string, error) {
 	if sc.StripMobilePrefix {
 		if IsMobileURL(url) {
 			url = StripMobilePrefix(url)
@@ -201,7 +324,7 @@ func (sc *API) prepareURL(url string) (string, error) {
 
 	if IsNewMobileURL(url) {
 		var err error
-		url, err = sc.ConvertNewMobileURL(url)
+		url, err = sc.ConvertNewMobileURLContext(ctx, url)
 		if err != nil {
 			return "", errors.Wrap(err, "failed to convert new mobile url")
 		}
@@ -210,7 +333,14 @@ func (sc *API) prepareURL(url string) (string, error) {
 	return url, nil
 }
 
+// ConvertNewMobileURL converts a link of the form (https://on.soundcloud.com/xxxxx) to a regular
+// SoundCloud link by following its redirects.
 func (sc *API) ConvertNewMobileURL(url string) (string, error) {
+	return sc.ConvertNewMobileURLContext(context.Background(), url)
+}
+
+// ConvertNewMobileURLContext is like ConvertNewMobileURL but with a context
+func (sc *API) ConvertNewMobileURLContext(ctx context.Context, url string) (string, error) {
 	client := new(http.Client)
 	type urlResp struct {
 		url *string
@@ -222,12 +352,22 @@ func (sc *API) ConvertNewMobileURL(url string) (string, error) {
 
 		if IsURL(u, false, false) {
 			urlChan <- urlResp{url: &u, err: nil}
+			// Stop at the first regular SoundCloud URL, we don't need its response
+			return http.ErrUseLastResponse
 		}
 
 		return nil
 	}
 
-	_, err := client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to make request")
+	}
+
+	res, err := client.Do(req)
+	if err == nil {
+		res.Body.Close()
+	}
 	select {
 	case urlR := <-urlChan:
 		if urlR.url == nil {
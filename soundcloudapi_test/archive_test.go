@@ -0,0 +1,166 @@
+package soundcloudapi_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func TestArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+	archive, err := soundcloudapi.OpenArchive(path)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	archive.MaxAttempts = 2
+
+	mp3 := newTranscoding("mp3_0_0", "progressive", "audio/mpeg", false)
+	aac := newTranscoding("aac_160k", "hls", "audio/mp4", false)
+
+	if !archive.ShouldDownload(1, mp3) {
+		t.Error("Expected a new track to be downloaded")
+	}
+	if err := archive.Downloaded(1, mp3, "a.mp3"); err != nil {
+		t.Error(err.Error())
+	}
+	if archive.ShouldDownload(1, mp3) {
+		t.Error("Expected a downloaded track not to be downloaded again")
+	}
+	if !archive.ShouldDownload(1, aac) {
+		t.Error("Expected another transcoding of a downloaded track to be downloaded")
+	}
+
+	for i := 0; i < 2; i++ {
+		if !archive.ShouldDownload(2, mp3) {
+			t.Errorf("Expected a track that failed %d times to be retried", i)
+		}
+		archive.Failed(2, mp3, errors.New("boom"))
+	}
+	if archive.ShouldDownload(2, mp3) {
+		t.Error("Expected a track that failed MaxAttempts times not to be retried")
+	}
+	archive.Close()
+
+	// A crash while writing leaves a line cut short
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"track_id":3,"preset":"mp3_0_0","mime_ty`)
+	f.Close()
+
+	archive, err = soundcloudapi.OpenArchive(path)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if err := archive.Downloaded(4, mp3, "d.mp3"); err != nil {
+		t.Error(err.Error())
+	}
+	archive.Close()
+
+	archive, err = soundcloudapi.OpenArchive(path)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer archive.Close()
+
+	if entry, ok := archive.Entry(1, mp3); !ok || entry.Status != soundcloudapi.ArchiveDownloaded || entry.Path != "a.mp3" {
+		t.Errorf("Expected track 1 to be downloaded to a.mp3, received (%+v)", entry)
+	}
+	if entry, ok := archive.Entry(2, mp3); !ok || entry.Status != soundcloudapi.ArchiveFailed || entry.Attempts != 2 || entry.Error != "boom" {
+		t.Errorf("Expected track 2 to have failed twice, received (%+v)", entry)
+	}
+	if _, ok := archive.Entry(3, mp3); ok {
+		t.Error("Expected the line cut short to be ignored")
+	}
+	if _, ok := archive.Entry(4, mp3); !ok {
+		t.Error("Expected the entry written after the line cut short to be read")
+	}
+}
+
+func TestArchiveConcurrency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+	archive, err := soundcloudapi.OpenArchive(path)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	mp3 := newTranscoding("mp3_0_0", "progressive", "audio/mpeg", false)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			archive.Failed(id, mp3, errors.New("boom"))
+			archive.Downloaded(id, mp3, fmt.Sprintf("%d.mp3", id))
+		}(int64(i))
+	}
+	wg.Wait()
+	archive.Close()
+
+	archive, err = soundcloudapi.OpenArchive(path)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer archive.Close()
+	for i := int64(0); i < 50; i++ {
+		if entry, _ := archive.Entry(i, mp3); entry.Status != soundcloudapi.ArchiveDownloaded || entry.Path != fmt.Sprintf("%d.mp3", i) {
+			t.Errorf("Expected track %d to be downloaded, received (%+v)", i, entry)
+		}
+	}
+}
+
+func TestDownloadPlaylistArchive(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	playlist := addMixtape(s)
+	sc := newTestAPI(t, s, nil)
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/media/soundcloud:tracks:925/", Status: 404})
+
+	dir := t.TempDir()
+	archive, err := soundcloudapi.OpenArchive(filepath.Join(dir, "archive.jsonl"))
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer archive.Close()
+	archive.MaxAttempts = 2
+
+	options := soundcloudapi.PlaylistDownloadOptions{Archive: archive}
+	for run := 0; run < 3; run++ {
+		cdnRequests := s.RequestCount("/cdn/")
+		mediaRequests := s.RequestCount("/media/soundcloud:tracks:925/")
+
+		results, err := sc.DownloadPlaylist(playlist, dir, options)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+
+		for i := 0; i < 3; i++ {
+			if results[i].Err != nil || results[i].Skipped != (run > 0) {
+				t.Errorf("Run %d: expected track %d to be skipped (%t), received (%t) (%v)", run, i+1, run > 0, results[i].Skipped, results[i].Err)
+			}
+		}
+		if run > 0 && s.RequestCount("/cdn/") != cdnRequests {
+			t.Errorf("Run %d: expected the archived tracks not to be downloaded again", run)
+		}
+
+		// The failing track is tried twice
+		failed := results[5]
+		if run < 2 {
+			if failed.Skipped || failed.Err == nil || s.RequestCount("/media/soundcloud:tracks:925/") == mediaRequests {
+				t.Errorf("Run %d: expected the failing track to be retried, received (%v)", run, failed.Err)
+			}
+		} else if !failed.Skipped || errors.Cause(failed.Err) != soundcloudapi.ErrTooManyFailures || s.RequestCount("/media/soundcloud:tracks:925/") != mediaRequests {
+			t.Errorf("Run %d: expected the failing track to be skipped with ErrTooManyFailures, received (%v)", run, failed.Err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package soundcloudapi_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+)
+
+func TestBaseURLs(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html>\n<script crossorigin src=\"%s/assets/app.js\"></script>\n</html>", server.URL)
+	})
+	mux.HandleFunc("/assets/app.js", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `window.__sc_config={env:"production",client_id:"localclientid"}`)
+	})
+	mux.HandleFunc("/resolve", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("client_id") != "localclientid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"kind":"track","id":1,"title":"Local"}`)
+	})
+
+	sc, err := soundcloudapi.New(soundcloudapi.APIOptions{
+		APIBaseURL:   server.URL,
+		WebBaseURL:   server.URL,
+		AssetBaseURL: server.URL + "/assets/",
+	})
+	if err != nil {
+		t.Errorf("failed to create new API: %+v\n", err)
+		return
+	}
+
+	if sc.ClientID() != "localclientid" {
+		t.Errorf("Expected: (%s), Received: (%s)\n", "localclientid", sc.ClientID())
+		return
+	}
+
+	tracks, err := sc.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/local/track"})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if tracks[0].Title != "Local" {
+		t.Errorf("Expected: (%s), Received: (%s)\n", "Local", tracks[0].Title)
+	}
+}
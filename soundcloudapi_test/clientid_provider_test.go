@@ -0,0 +1,182 @@
+package soundcloudapi_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func TestFileCacheClientIDProvider(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "soundcloudapi")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	provider := &soundcloudapi.FileCacheClientIDProvider{
+		Provider: s.ClientIDProvider(),
+		Path:     filepath.Join(dir, "client_id.json"),
+		TTL:      time.Hour,
+	}
+
+	for i := 0; i < 3; i++ {
+		clientID, err := provider.ClientID(context.Background())
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if clientID != s.ClientID() {
+			t.Errorf("Expected: (%s), Received: (%s)\n", s.ClientID(), clientID)
+			return
+		}
+	}
+
+	if count := s.RequestCount("/web"); count != 1 {
+		t.Errorf("Expected the client ID to be scraped once, scraped (%d) times", count)
+	}
+
+	// A new provider with the same path shares the cache
+	cached := &soundcloudapi.FileCacheClientIDProvider{Path: provider.Path}
+	if clientID, err := cached.ClientID(context.Background()); err != nil || clientID != s.ClientID() {
+		t.Errorf("Expected cached client ID (%s), received (%s) (%v)", s.ClientID(), clientID, err)
+	}
+
+	s.RotateClientID("rotatedclientid")
+	if err := provider.InvalidateClientID(context.Background(), soundcloudtest.DefaultClientID); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if clientID, err := provider.ClientID(context.Background()); err != nil || clientID != "rotatedclientid" {
+		t.Errorf("Expected client ID (%s) after invalidation, received (%s) (%v)", "rotatedclientid", clientID, err)
+	}
+}
+
+func TestChainClientIDProvider(t *testing.T) {
+	chain := soundcloudapi.ChainClientIDProvider{
+		soundcloudapi.StaticClientIDProvider(""),
+		soundcloudapi.StaticClientIDProvider("fallback"),
+	}
+
+	clientID, err := chain.ClientID(context.Background())
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if clientID != "fallback" {
+		t.Errorf("Expected: (%s), Received: (%s)\n", "fallback", clientID)
+	}
+
+	if _, err := (soundcloudapi.ChainClientIDProvider{}).ClientID(context.Background()); err == nil {
+		t.Error("Expected an empty chain to fail")
+	}
+}
+
+func TestNewWithClientIDProvider(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	defer s.Close()
+	addFixtures(s)
+
+	dir, err := ioutil.TempDir("", "soundcloudapi")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "client_id.json")
+	err = ioutil.WriteFile(path, []byte(`{"client_id":"staleclientid","fetched_at":"`+time.Now().Format(time.RFC3339)+`"}`), 0600)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	options := s.APIOptions()
+	options.ClientID = ""
+	options.ClientIDProvider = &soundcloudapi.FileCacheClientIDProvider{
+		Provider: s.ClientIDProvider(),
+		Path:     path,
+	}
+	options.AutoRefreshClientID = true
+
+	sc, err := soundcloudapi.New(options)
+	if err != nil {
+		t.Errorf("failed to create new API: %+v\n", err)
+		return
+	}
+
+	if sc.ClientID() != "staleclientid" {
+		t.Errorf("Expected: (%s), Received: (%s)\n", "staleclientid", sc.ClientID())
+		return
+	}
+
+	// The cached client ID is rejected, so it should be invalidated and replaced by a scraped one
+	_, err = sc.GetUser(soundcloudapi.GetUserOptions{ID: 2})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if clientID, err := options.ClientIDProvider.ClientID(context.Background()); err != nil || clientID != s.ClientID() {
+		t.Errorf("Expected cached client ID (%s), received (%s) (%v)", s.ClientID(), clientID, err)
+	}
+}
+
+func TestFileCacheClientIDProviderWriteError(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	defer s.Close()
+
+	// The cache can't be written, since its directory is a file
+	file := filepath.Join(t.TempDir(), "file")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	provider := &soundcloudapi.FileCacheClientIDProvider{
+		Provider: s.ClientIDProvider(),
+		Path:     filepath.Join(file, "client_id.json"),
+	}
+	if clientID, err := provider.ClientID(context.Background()); err != nil || clientID != s.ClientID() {
+		t.Errorf("Expected client ID (%s), received (%s) (%v)", s.ClientID(), clientID, err)
+	}
+}
+
+// unsetEnv unsets the environment variables until the test ends
+func unsetEnv(t *testing.T, keys ...string) {
+	for _, key := range keys {
+		key := key
+		if value, ok := os.LookupEnv(key); ok {
+			t.Cleanup(func() { os.Setenv(key, value) })
+		}
+		os.Unsetenv(key)
+	}
+}
+
+func TestFileCacheClientIDProviderNoCacheDir(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	defer s.Close()
+
+	// The default path can't be resolved without a home or cache directory
+	unsetEnv(t, "HOME", "XDG_CACHE_HOME", "LocalAppData", "home")
+	if _, err := soundcloudapi.DefaultClientIDCachePath(); err == nil {
+		t.Skip("the user cache directory can be resolved without HOME on this platform")
+	}
+
+	provider := &soundcloudapi.FileCacheClientIDProvider{Provider: s.ClientIDProvider()}
+	if clientID, err := provider.ClientID(context.Background()); err != nil || clientID != s.ClientID() {
+		t.Errorf("Expected client ID (%s), received (%s) (%v)", s.ClientID(), clientID, err)
+	}
+	if err := provider.InvalidateClientID(context.Background(), s.ClientID()); err != nil {
+		t.Error(err.Error())
+	}
+}
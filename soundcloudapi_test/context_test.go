@@ -0,0 +1,52 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func TestContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := api.GetTrackInfoContext(ctx, soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from GetTrackInfoContext, received: (%v)", err)
+	}
+
+	_, err = api.GetPlaylistInfoContext(ctx, "https://soundcloud.com/ilyanaazman/sets/best-of-mrrevillz")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from GetPlaylistInfoContext, received: (%v)", err)
+	}
+
+	track, _ := server.Track(929590315)
+	err = api.DownloadTrackContext(ctx, track.Media.Transcodings[0], &bytes.Buffer{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from DownloadTrackContext, received: (%v)", err)
+	}
+}
+
+func TestGetPlaylistInfoWorkerError(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	defer s.Close()
+	addFixtures(s)
+
+	sc, err := soundcloudapi.New(s.APIOptions())
+	if err != nil {
+		t.Errorf("failed to create new API: %+v\n", err)
+		return
+	}
+
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/tracks", Status: http.StatusInternalServerError})
+
+	_, err = sc.GetPlaylistInfoContext(context.Background(), "https://soundcloud.com/ilyanaazman/sets/best-of-mrrevillz")
+	if failedRequest, ok := err.(*soundcloudapi.FailedRequestError); !ok || failedRequest.Status != http.StatusInternalServerError {
+		t.Errorf("Expected FailedRequestError with status (%d), received: (%v)", http.StatusInternalServerError, err)
+	}
+}
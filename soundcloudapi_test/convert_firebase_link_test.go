@@ -7,6 +7,8 @@ import (
 )
 
 func TestConvertFirebaseLinkPrefix(t *testing.T) {
+	requireLive(t)
+
 	raw := "https://soundcloud.app.goo.gl/z8snjNyHU8zMHH29A"
 	expected := "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the?ref=clipboard&p=i&c=0"
 
@@ -0,0 +1,242 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+const originalTrackURL = "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"
+
+func checkFile(t *testing.T, path string, expected []byte) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if !bytes.Equal(data, expected) {
+		t.Errorf("%s does not match, received %d bytes", filepath.Base(path), len(data))
+	}
+
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Errorf("Expected %s.part to be removed", filepath.Base(path))
+	}
+}
+
+func TestDownloadTrackToFile(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, nil)
+	dir := t.TempDir()
+
+	track, _ := s.Track(929590315)
+	for _, transcoding := range track.Media.Transcodings {
+		path := filepath.Join(dir, transcoding.Format.Protocol+".mp3")
+		if err := sc.DownloadTrackToFile(transcoding, path); err != nil {
+			t.Errorf("Failed to download %s transcoding: %s", transcoding.Format.Protocol, err.Error())
+			continue
+		}
+		checkFile(t, path, audioData(4000, 1))
+	}
+}
+
+func TestDownloadTrackToFileWithOptions(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, nil)
+	dir := t.TempDir()
+
+	track, _ := s.Track(929590315)
+	track.ArtworkURL = ""
+	for _, transcoding := range track.Media.Transcodings {
+		path := filepath.Join(dir, transcoding.Format.Protocol+".mp3")
+		var last soundcloudapi.Progress
+		err := sc.DownloadTrackToFileWithOptions(context.Background(), transcoding, path, soundcloudapi.DownloadOptions{
+			Tag:      &track,
+			Progress: func(progress soundcloudapi.Progress) { last = progress },
+		})
+		if err != nil {
+			t.Errorf("Failed to download %s transcoding: %s", transcoding.Format.Protocol, err.Error())
+			continue
+		}
+
+		data, _ := ioutil.ReadFile(path)
+		frames, audio := id3Frames(t, data)
+		if string(frames["TIT2"]) != "\x03"+track.Title {
+			t.Errorf("Expected the %s download to be tagged with the title, received (%q)", transcoding.Format.Protocol, frames["TIT2"])
+		}
+		if !bytes.Equal(audio, audioData(4000, 1)) {
+			t.Errorf("Expected the %s audio to follow the tag, received %d bytes", transcoding.Format.Protocol, len(audio))
+		}
+		if last.BytesWritten != 4000 {
+			t.Errorf("Expected (4000) bytes written for %s transcoding, received (%d)", transcoding.Format.Protocol, last.BytesWritten)
+		}
+	}
+}
+
+func TestResumeDownloadTrackToFileWithOptions(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, nil)
+	path := filepath.Join(t.TempDir(), "progressive.mp3")
+
+	track, _ := s.Track(929590315)
+	track.ArtworkURL = ""
+	transcoding, err := soundcloudapi.SelectTranscoding(track, soundcloudapi.TranscodingPolicy{Protocols: []string{"progressive"}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/cdn/", DropAfter: 1500, Times: 1})
+	if err := sc.DownloadTrackToFile(transcoding, path); err == nil {
+		t.Error("Expected the dropped connection to fail the download")
+		return
+	}
+
+	// The .part file only has the audio, and the tag is written once it's complete
+	reports := []soundcloudapi.Progress{}
+	err = sc.DownloadTrackToFileWithOptions(context.Background(), transcoding, path, soundcloudapi.DownloadOptions{
+		Tag:      &track,
+		Progress: func(progress soundcloudapi.Progress) { reports = append(reports, progress) },
+	})
+	if err != nil {
+		t.Errorf("Failed to resume download: %s", err.Error())
+		return
+	}
+
+	if len(reports) == 0 || reports[0].BytesWritten != 1500 || reports[len(reports)-1].BytesWritten != 4000 {
+		t.Errorf("Expected the progress to go from (1500) to (4000) bytes, received (%v)", reports)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	if _, audio := id3Frames(t, data); !bytes.Equal(audio, audioData(4000, 1)) {
+		t.Errorf("Expected the audio to follow the tag, received %d bytes", len(audio))
+	}
+	for _, suffix := range []string{".part", ".part.id3", ".part.json"} {
+		if _, err := os.Stat(path + suffix); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", filepath.Base(path+suffix))
+		}
+	}
+}
+
+func TestResumeDownload(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, nil)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "original.wav")
+
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/cdn/", DropAfter: 5000, Times: 1})
+	if err := sc.DownloadOriginalToFile(originalTrackURL, path); err == nil {
+		t.Error("Expected the dropped connection to fail the download")
+		return
+	}
+
+	if info, err := os.Stat(path + ".part"); err != nil || info.Size() != 5000 {
+		t.Errorf("Expected a 5000 bytes .part file, received (%v, %v)", info, err)
+		return
+	}
+
+	// Only the remaining 3000 bytes are requested, so this fault doesn't apply
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/cdn/", DropAfter: 4000, Times: 1})
+	if err := sc.DownloadOriginalToFile(originalTrackURL, path); err != nil {
+		t.Errorf("Failed to resume download: %s", err.Error())
+		return
+	}
+
+	checkFile(t, path, audioData(8000, 2))
+}
+
+func TestResumeDownloadFileChanged(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, nil)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "original.wav")
+
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/cdn/", DropAfter: 5000, Times: 1})
+	sc.DownloadOriginalToFile(originalTrackURL, path)
+
+	// The ETag doesn't match anymore, so the whole file is downloaded again
+	s.SetOriginal(929590315, audioData(6000, 3))
+	if err := sc.DownloadOriginalToFile(originalTrackURL, path); err != nil {
+		t.Errorf("Failed to download: %s", err.Error())
+		return
+	}
+
+	checkFile(t, path, audioData(6000, 3))
+}
+
+func TestResumeDownloadExpiredURL(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+		options.RetryPolicy = soundcloudapi.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, RetryNetworkErrors: true}
+	})
+	dir := t.TempDir()
+	path := filepath.Join(dir, "original.wav")
+
+	// The connection drops, and the signed URL has expired by the time the download is resumed
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/cdn/", DropAfter: 5000, Times: 1})
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/cdn/", Status: http.StatusForbidden, Times: 1})
+
+	if err := sc.DownloadOriginalToFile(originalTrackURL, path); err != nil {
+		t.Errorf("Failed to download: %s", err.Error())
+		return
+	}
+
+	checkFile(t, path, audioData(8000, 2))
+
+	if count := s.RequestCount("/tracks/929590315/download"); count != 2 {
+		t.Errorf("Expected the download URL to be fetched (2) times, received (%d)", count)
+	}
+}
+
+func TestDownloadToFileRetries(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+		options.RetryPolicy = soundcloudapi.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	})
+	path := filepath.Join(t.TempDir(), "original.wav")
+
+	// The download URL is fetched once per attempt of the download, not retried within each attempt
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/tracks/929590315/download", Status: http.StatusServiceUnavailable})
+	if err := sc.DownloadOriginalToFile(originalTrackURL, path); err == nil {
+		t.Error("Expected the download to fail")
+	}
+	if count := s.RequestCount("/tracks/929590315/download"); count != 3 {
+		t.Errorf("Expected the download URL to be fetched (3) times, received (%d)", count)
+	}
+}
+
+func TestDownloadTrackToFileHLSError(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, nil)
+	dir := t.TempDir()
+
+	track, _ := s.Track(929590315)
+	for _, transcoding := range track.Media.Transcodings {
+		if transcoding.Format.Protocol != "hls" {
+			continue
+		}
+
+		s.InjectFault(soundcloudtest.Fault{PathPrefix: "/cdn/", Status: http.StatusNotFound})
+		path := filepath.Join(dir, "hls.mp3")
+		if err := sc.DownloadTrackToFile(transcoding, path); err == nil {
+			t.Error("Expected the download to fail")
+		}
+		if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+			t.Error("Expected hls.mp3.part to be removed")
+		}
+	}
+}
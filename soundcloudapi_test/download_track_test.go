@@ -0,0 +1,23 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDownloadTrack(t *testing.T) {
+	track, _ := server.Track(929590315)
+
+	for _, transcoding := range track.Media.Transcodings {
+		buf := &bytes.Buffer{}
+		err := api.DownloadTrack(transcoding, buf)
+		if err != nil {
+			t.Errorf("Failed to download %s transcoding: %s", transcoding.Format.Protocol, err.Error())
+			continue
+		}
+
+		if !bytes.Equal(buf.Bytes(), audioData(4000, 1)) {
+			t.Errorf("Downloaded %s transcoding does not match, received %d bytes", transcoding.Format.Protocol, buf.Len())
+		}
+	}
+}
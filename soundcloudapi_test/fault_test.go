@@ -0,0 +1,72 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func TestFailedRequestError(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	defer s.Close()
+	addFixtures(s)
+
+	sc, err := soundcloudapi.New(s.APIOptions())
+	if err != nil {
+		t.Errorf("failed to create new API: %+v\n", err)
+		return
+	}
+
+	for _, status := range []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		s.InjectFault(soundcloudtest.Fault{PathPrefix: "/resolve", Status: status, Times: 1})
+
+		_, err = sc.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+		if failedRequest, ok := err.(*soundcloudapi.FailedRequestError); !ok || failedRequest.Status != status {
+			t.Errorf("Expected FailedRequestError with status (%d), received: (%v)", status, err)
+		}
+	}
+
+	_, err = sc.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+	if err != nil {
+		t.Errorf("Expected fault to be removed, received: %s", err.Error())
+	}
+}
+
+func TestExpiredMediaURL(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	defer s.Close()
+	addFixtures(s)
+
+	sc, err := soundcloudapi.New(s.APIOptions())
+	if err != nil {
+		t.Errorf("failed to create new API: %+v\n", err)
+		return
+	}
+
+	dlURL, err := sc.GetDownloadURL("https://soundcloud.com/childish-gambino/redbone", "hls")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	s.ExpireMediaURLs()
+
+	res, err := s.Client().Get(dlURL)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status (%d) for expired media URL, received (%d)", http.StatusForbidden, res.StatusCode)
+	}
+
+	track, _ := s.Track(122144511)
+	if err := sc.DownloadTrack(track.Media.Transcodings[0], &bytes.Buffer{}); err != nil {
+		t.Errorf("Expected a fresh media URL to work, received: %s", err.Error())
+	}
+}
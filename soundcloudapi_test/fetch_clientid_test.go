@@ -1,14 +1,21 @@
 package soundcloudapi_test
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/pkg/errors"
 	soundcloudapi "github.com/zackradisic/soundcloud-api"
 )
 
 func TestFetchClientID(t *testing.T) {
+	requireLive(t)
+
 	clientID, err := soundcloudapi.FetchClientID()
 	if err != nil {
 		t.Errorf("Failed to fetch client ID: %+v\n", err)
@@ -26,3 +33,133 @@ func TestFetchClientID(t *testing.T) {
 		return
 	}
 }
+
+// newScraperServer starts a web app that imports scripts and an API that only accepts validClientID
+func newScraperServer(scripts []string, validClientID string) (*httptest.Server, *soundcloudapi.ScrapingClientIDProvider) {
+	mux := http.NewServeMux()
+	s := httptest.NewServer(mux)
+
+	mux.HandleFunc("/web", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html>\n<script>var a = 1;</script>\n<script crossorigin src=\"x\"></script>\n")
+		for i := range scripts {
+			fmt.Fprintf(w, "<script crossorigin src=\"%s/assets/%d.js\"></script>\n", s.URL, i)
+		}
+		fmt.Fprint(w, "</html>")
+	})
+	mux.HandleFunc("/assets/", func(w http.ResponseWriter, r *http.Request) {
+		var i int
+		fmt.Sscanf(r.URL.Path, "/assets/%d.js", &i)
+		fmt.Fprint(w, scripts[i])
+	})
+	mux.HandleFunc("/search/tracks", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("client_id") != validClientID {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"collection":[]}`)
+	})
+
+	return s, &soundcloudapi.ScrapingClientIDProvider{
+		HTTPClient:   s.Client(),
+		WebBaseURL:   s.URL + "/web",
+		AssetBaseURL: s.URL + "/assets/",
+		APIBaseURL:   s.URL,
+	}
+}
+
+func TestScrapingClientIDProvider(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		scripts  []string
+		expected string
+	}{
+		{"object literal", []string{`var a={}`, `e={env:"production",client_id:"objectliteralclientid"}`}, "objectliteralclientid"},
+		{"assignment", []string{`var client_id = 'assignmentclientid';`}, "assignmentclientid"},
+		{"query parameter", []string{`fetch("https://api-v2.soundcloud.com/me?client_id=queryclientid&app_version=1")`}, "queryclientid"},
+		{"rejected candidate", []string{`{client_id:"validclientid"}`, `{client_id:"staleclientid"}`}, "validclientid"},
+	} {
+		s, provider := newScraperServer(test.scripts, test.expected)
+
+		clientID, err := provider.ClientID(context.Background())
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+		} else if clientID != test.expected {
+			t.Errorf("%s: Expected: (%s), Received: (%s)\n", test.name, test.expected, clientID)
+		}
+
+		s.Close()
+	}
+}
+
+func TestScrapingClientIDProviderErrors(t *testing.T) {
+	s, provider := newScraperServer(nil, "")
+	_, err := provider.ClientID(context.Background())
+	if !errors.Is(err, soundcloudapi.ErrNoAssetScripts) {
+		t.Errorf("Expected ErrNoAssetScripts, received: (%v)", err)
+	}
+	s.Close()
+
+	s, provider = newScraperServer([]string{`var a={}`}, "")
+	_, err = provider.ClientID(context.Background())
+	if !errors.Is(err, soundcloudapi.ErrClientIDNotFound) {
+		t.Errorf("Expected ErrClientIDNotFound, received: (%v)", err)
+	}
+	s.Close()
+
+	s, provider = newScraperServer([]string{`{client_id:"staleclientid"}`}, "validclientid")
+	_, err = provider.ClientID(context.Background())
+	if invalid, ok := err.(*soundcloudapi.InvalidClientIDError); !ok || len(invalid.ClientIDs) != 1 {
+		t.Errorf("Expected InvalidClientIDError, received: (%v)", err)
+	}
+	s.Close()
+}
+
+// failingTransport responds with a status to the requests whose path and query contain a key of failures
+type failingTransport struct {
+	failures map[string]int
+}
+
+func (f *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for substr, status := range f.failures {
+		if strings.Contains(req.URL.RequestURI(), substr) {
+			return &http.Response{StatusCode: status, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+		}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestScrapingClientIDProviderTransientErrors(t *testing.T) {
+	// A script that can't be loaded is skipped
+	s, provider := newScraperServer([]string{`{client_id:"validclientid"}`, `var a={}`}, "validclientid")
+	provider.HTTPClient = &http.Client{Transport: &failingTransport{failures: map[string]int{"/assets/1.js": http.StatusInternalServerError}}}
+	clientID, err := provider.ClientID(context.Background())
+	if err != nil || clientID != "validclientid" {
+		t.Errorf("Expected (validclientid), received (%s) (%v)", clientID, err)
+	}
+
+	// A failed validation doesn't reject the client ID
+	provider.HTTPClient = &http.Client{Transport: &failingTransport{failures: map[string]int{"client_id=validclientid": http.StatusServiceUnavailable}}}
+	_, err = provider.ClientID(context.Background())
+	if failedRequest, ok := errors.Cause(err).(*soundcloudapi.FailedRequestError); !ok || failedRequest.Status != http.StatusServiceUnavailable {
+		t.Errorf("Expected FailedRequestError with status (%d), received (%v)", http.StatusServiceUnavailable, err)
+	}
+	s.Close()
+
+	// and the next candidate is tried
+	s, provider = newScraperServer([]string{`{client_id:"validclientid"}`, `{client_id:"throttledclientid"}`}, "validclientid")
+	provider.HTTPClient = &http.Client{Transport: &failingTransport{failures: map[string]int{"client_id=throttledclientid": http.StatusTooManyRequests}}}
+	clientID, err = provider.ClientID(context.Background())
+	if err != nil || clientID != "validclientid" {
+		t.Errorf("Expected (validclientid), received (%s) (%v)", clientID, err)
+	}
+	s.Close()
+
+	// Only when no script could be loaded is the error returned
+	s, provider = newScraperServer([]string{`{client_id:"validclientid"}`}, "validclientid")
+	provider.HTTPClient = &http.Client{Transport: &failingTransport{failures: map[string]int{"/assets/": http.StatusBadGateway}}}
+	_, err = provider.ClientID(context.Background())
+	if failedRequest, ok := errors.Cause(err).(*soundcloudapi.FailedRequestError); !ok || failedRequest.Status != http.StatusBadGateway {
+		t.Errorf("Expected FailedRequestError with status (%d), received (%v)", http.StatusBadGateway, err)
+	}
+	s.Close()
+}
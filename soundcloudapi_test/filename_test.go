@@ -0,0 +1,128 @@
+package soundcloudapi_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+)
+
+func TestFilenameTemplate(t *testing.T) {
+	tmpl, err := soundcloudapi.NewFilenameTemplate(`{{.User.Username}}/{{.Playlist.Title}}/{{.Index | printf "%02d"}} - {{.Title}}.{{.Ext}}`)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	tests := []struct {
+		username string
+		playlist string
+		title    string
+		mimeType string
+		expected string
+	}{
+		{"dj", "Mixtape", "Intro", "audio/mpeg", "dj/Mixtape/07 - Intro.mp3"},
+		{"dj", "Mixtape", "Intro", `audio/mp4; codecs="mp4a.40.2"`, "dj/Mixtape/07 - Intro.m4a"},
+		{"dj", "Mixtape", "Intro", `audio/ogg; codecs="opus"`, "dj/Mixtape/07 - Intro.opus"},
+		{"a/b", "Live\\Set", "AC/DC: Back in Black?", "audio/mpeg", "a_b/Live_Set/07 - AC_DC_ Back in Black_.mp3"},
+		{"dj", "Album...", "Outro. ", "audio/mpeg", "dj/Album/07 - Outro.mp3"},
+		{"dj", "", "Tab\there", "audio/mpeg", "dj/07 - Tab_here.mp3"},
+		{"CON", "lpt1.d", "x", "audio/mpeg", "CON_/lpt1_.d/07 - x.mp3"},
+	}
+
+	for _, test := range tests {
+		track := newTrack(1, newUser(2, test.username), "t", test.title)
+		file := soundcloudapi.NewTrackFile(track, soundcloudapi.Transcoding{Format: soundcloudapi.TranscodingFormat{MimeType: test.mimeType}})
+		file.Index = 7
+		file.Playlist = soundcloudapi.Playlist{Title: test.playlist}
+
+		path, err := tmpl.Execute(file)
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+		if expected := filepath.FromSlash(test.expected); path != expected {
+			t.Errorf("Expected path (%s), received (%s)", expected, path)
+		}
+	}
+}
+
+func TestFilenameTemplateReservedNames(t *testing.T) {
+	tmpl, err := soundcloudapi.NewFilenameTemplate(`{{.Title}}.{{.Ext}}`)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	for title, expected := range map[string]string{"con": "con_.mp3", "NUL.tar": "NUL_.tar.mp3", "console": "console.mp3", "COM10": "COM10.mp3"} {
+		file := soundcloudapi.NewTrackFile(newTrack(1, newUser(2, "dj"), "t", title), soundcloudapi.Transcoding{Format: soundcloudapi.TranscodingFormat{MimeType: "audio/mpeg"}})
+		if path, err := tmpl.Execute(file); err != nil || path != expected {
+			t.Errorf("Expected path (%s) for title (%s), received (%s) (%v)", expected, title, path, err)
+		}
+	}
+}
+
+func TestFilenameTemplateLongNames(t *testing.T) {
+	tmpl, err := soundcloudapi.NewFilenameTemplate(`{{.Title}}/{{.Title}}.{{.Ext}}`)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	file := soundcloudapi.NewTrackFile(newTrack(1, newUser(2, "dj"), "t", strings.Repeat("é", 300)), soundcloudapi.Transcoding{Format: soundcloudapi.TranscodingFormat{MimeType: "audio/mpeg"}})
+	path, err := tmpl.Execute(file)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	dir, name := filepath.Split(path)
+	dir = strings.TrimSuffix(dir, string(filepath.Separator))
+	for _, component := range []string{dir, name} {
+		if len(component) > 255 || !utf8.ValidString(component) {
+			t.Errorf("Expected a valid name of at most 255 bytes, received %d bytes", len(component))
+		}
+	}
+	if !strings.HasSuffix(name, ".mp3") {
+		t.Errorf("Expected the extension to be kept, received (%s)", name[len(name)-10:])
+	}
+}
+
+func TestDedupePaths(t *testing.T) {
+	paths := soundcloudapi.DedupePaths([]string{"a.mp3", "A.mp3", "a.mp3", "a (2).mp3", "b.mp3", "dir/a.mp3"})
+	expected := []string{"a.mp3", "A (3).mp3", "a (4).mp3", "a (2).mp3", "b.mp3", "dir/a.mp3"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected (%v), received (%v)", expected, paths)
+	}
+}
+
+func TestDedupePathsLongNames(t *testing.T) {
+	tmpl, err := soundcloudapi.NewFilenameTemplate(`{{.Title}}/{{.Title}}.{{.Ext}}`)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	// The title is truncated to fill the 255 bytes, so the suffix takes the place of its end
+	file := soundcloudapi.NewTrackFile(newTrack(1, newUser(2, "dj"), "t", strings.Repeat("a", 255)), soundcloudapi.Transcoding{Format: soundcloudapi.TranscodingFormat{MimeType: "audio/mpeg"}})
+	path, err := tmpl.Execute(file)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	paths := soundcloudapi.DedupePaths([]string{path, path})
+	if paths[0] != path {
+		t.Errorf("Expected the first path to be kept, received (%s)", paths[0])
+	}
+	dir, name := filepath.Split(paths[1])
+	if dir != filepath.Dir(path)+string(filepath.Separator) {
+		t.Errorf("Expected the directory to be kept, received (%s)", dir)
+	}
+	if len(name) != 255 || !strings.HasSuffix(name, " (2).mp3") {
+		t.Errorf("Expected a name of 255 bytes ending in ( (2).mp3), received %d bytes (%s)", len(name), name)
+	}
+}
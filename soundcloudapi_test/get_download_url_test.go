@@ -14,7 +14,7 @@ func TestGetDownloadURL(t *testing.T) {
 		return
 	}
 
-	if !strings.Contains(dlURL, "sndcdn.com/") {
+	if !strings.HasPrefix(dlURL, server.URL+"/cdn/") {
 		t.Errorf("Invalid download URL returned, received: (%s)", dlURL)
 	}
 }
@@ -40,12 +40,14 @@ func TestGetDownloadURLPublic(t *testing.T) {
 		return
 	}
 
-	if !strings.Contains(dlURL, "sndcdn.com/") {
+	if !strings.HasPrefix(dlURL, server.URL+"/cdn/") {
 		t.Errorf("Invalid download URL returned, received: (%s)", dlURL)
 	}
 }
 
 func TestGetDownloadURLNewLink(t *testing.T) {
+	api := liveAPI(t)
+
 	// This track has a public download URL link
 	trackInfo, err := api.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{
 		URL: "https://on.soundcloud.com/t1Jie",
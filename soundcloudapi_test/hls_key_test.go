@@ -0,0 +1,123 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func TestHLSDecryption(t *testing.T) {
+	keys := map[int][]byte{0: bytes.Repeat([]byte{1}, 16), 6: bytes.Repeat([]byte{2}, 16)}
+	tests := []struct {
+		name          string
+		iv            []byte
+		mediaSequence int
+	}{
+		{"sequence number IV", nil, 0},
+		{"sequence number IV with media sequence", nil, 1234},
+		{"explicit IV", []byte("0123456789abcdef"), 0},
+	}
+
+	for _, test := range tests {
+		s := soundcloudtest.NewServer()
+		transcoding := addRemuxTrack(s, soundcloudtest.Audio{
+			Preset: "mp3_0_0", Protocol: "hls", MimeType: "audio/mpeg",
+			Data: audioData(1000, 5), SegmentSize: 100, Keys: keys, IV: test.iv, MediaSequence: test.mediaSequence,
+		})
+		sc := newTestAPI(t, s, nil)
+
+		buf := &bytes.Buffer{}
+		if err := sc.DownloadTrack(transcoding, buf); err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+		} else if !bytes.Equal(buf.Bytes(), audioData(1000, 5)) {
+			t.Errorf("%s: decrypted track does not match", test.name)
+		}
+
+		// Each key is only fetched once
+		for _, path := range []string{"/cdn/901/0/key/0", "/cdn/901/0/key/6"} {
+			if count := s.RequestCount(path); count != 1 {
+				t.Errorf("%s: expected (1) request to %s, received (%d)", test.name, path, count)
+			}
+		}
+	}
+}
+
+func TestHLSDecryptionInitSection(t *testing.T) {
+	fragments := [][][]byte{}
+	for i := 0; i < 3; i++ {
+		fragments = append(fragments, [][]byte{audioData(300, byte(i))})
+	}
+	init, segments := soundcloudtest.FragmentedMP4(44100, 1024, fragments)
+	download := func(audio soundcloudtest.Audio) ([]byte, error) {
+		audio.Preset, audio.Protocol, audio.MimeType = "aac_160k", "hls", `audio/mp4; codecs="mp4a.40.2"`
+		audio.Segments, audio.Inits = segments, map[int][]byte{0: init}
+		s := soundcloudtest.NewServer()
+		transcoding := addRemuxTrack(s, audio)
+		buf := &bytes.Buffer{}
+		err := newTestAPI(t, s, nil).DownloadTrack(transcoding, buf)
+		return buf.Bytes(), err
+	}
+
+	expected, err := download(soundcloudtest.Audio{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// The initialization section is only encrypted if the key has an explicit IV,
+	// the IV can't come from a sequence number it doesn't have
+	for _, test := range []struct {
+		name string
+		iv   []byte
+	}{
+		{"sequence number IV", nil},
+		{"explicit IV", []byte("0123456789abcdef")},
+	} {
+		data, err := download(soundcloudtest.Audio{Keys: map[int][]byte{0: bytes.Repeat([]byte{1}, 16)}, IV: test.iv})
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+		} else if !bytes.Equal(data, expected) {
+			t.Errorf("%s: decrypted track does not match", test.name)
+		}
+	}
+}
+
+func TestHLSUnsupportedEncryption(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	transcoding := addRemuxTrack(s, soundcloudtest.Audio{
+		Preset: "mp3_0_0", Protocol: "hls", MimeType: "audio/mpeg",
+		Data: audioData(1000, 5), SegmentSize: 100, Keys: map[int][]byte{0: bytes.Repeat([]byte{1}, 16)}, EncryptionMethod: "SAMPLE-AES",
+	})
+	sc := newTestAPI(t, s, nil)
+
+	buf := &bytes.Buffer{}
+	err := sc.DownloadTrack(transcoding, buf)
+	encryptionErr, ok := errors.Cause(err).(*soundcloudapi.UnsupportedEncryptionError)
+	if !ok {
+		t.Errorf("Expected an UnsupportedEncryptionError, received (%v)", err)
+		return
+	}
+	if encryptionErr.Method != "SAMPLE-AES" {
+		t.Errorf("Expected method (SAMPLE-AES), received (%s)", encryptionErr.Method)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing to be written, received %d bytes", buf.Len())
+	}
+}
+
+func TestHLSKeyError(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	transcoding := addRemuxTrack(s, soundcloudtest.Audio{
+		Preset: "mp3_0_0", Protocol: "hls", MimeType: "audio/mpeg",
+		Data: audioData(1000, 5), SegmentSize: 100, Keys: map[int][]byte{0: bytes.Repeat([]byte{1}, 16), 3: bytes.Repeat([]byte{3}, 16)},
+	})
+	sc := newTestAPI(t, s, nil)
+
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/cdn/901/0/key/3", Status: 404})
+	err := sc.DownloadTrack(transcoding, &bytes.Buffer{})
+	if failedErr, ok := errors.Cause(err).(*soundcloudapi.FailedRequestError); !ok || failedErr.Status != 404 {
+		t.Errorf("Expected a 404 FailedRequestError when a key can't be fetched, received (%v)", err)
+	}
+}
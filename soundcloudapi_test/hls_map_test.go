@@ -0,0 +1,144 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func TestHLSInitSection(t *testing.T) {
+	fragments := [][][]byte{}
+	for i := 0; i < 3; i++ {
+		fragments = append(fragments, [][]byte{audioData(300, byte(i)), audioData(310, byte(i+10))})
+	}
+	init, segments := soundcloudtest.FragmentedMP4(44100, 1024, fragments)
+
+	s := soundcloudtest.NewServer()
+	transcoding := addRemuxTrack(s, soundcloudtest.Audio{
+		Preset: "aac_160k", Protocol: "hls", MimeType: `audio/mp4; codecs="mp4a.40.2"`,
+		Segments: segments, Inits: map[int][]byte{0: init},
+	})
+	sc := newTestAPI(t, s, nil)
+
+	buf := &bytes.Buffer{}
+	if err := sc.DownloadTrack(transcoding, buf); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	data := buf.Bytes()
+	if order, _ := mp4Children(t, data); len(order) != 3 || order[1] != "moov" {
+		t.Errorf("Expected (ftyp, moov, mdat) boxes, received (%v)", order)
+		return
+	}
+	stsz := mp4Path(t, data, "moov", "trak", "mdia", "minf", "stbl", "stsz")
+	if count := binary.BigEndian.Uint32(stsz[8:12]); count != 6 {
+		t.Errorf("Expected (6) samples, received (%d)", count)
+	}
+	if count := s.RequestCount("/cdn/901/0/init/"); count != 1 {
+		t.Errorf("Expected (1) init section request, received (%d)", count)
+	}
+}
+
+func TestHLSInitSectionChange(t *testing.T) {
+	segments := [][]byte{}
+	for i := 0; i < 5; i++ {
+		segments = append(segments, audioData(100, byte(i)))
+	}
+	inits := map[int][]byte{0: []byte("first init"), 3: []byte("second init")}
+
+	s := soundcloudtest.NewServer()
+	transcoding := addRemuxTrack(s, soundcloudtest.Audio{
+		Preset: "mp3_0_0", Protocol: "hls", MimeType: "audio/mpeg",
+		Segments: segments, Inits: inits,
+	})
+	sc := newTestAPI(t, s, nil)
+
+	buf := &bytes.Buffer{}
+	if err := sc.DownloadTrack(transcoding, buf); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	expected := bytes.Join([][]byte{inits[0], segments[0], segments[1], segments[2], inits[3], segments[3], segments[4]}, nil)
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("Downloaded track does not match, received %d bytes", buf.Len())
+	}
+}
+
+func TestHLSInitSectionChangeRemux(t *testing.T) {
+	fragments := [][][]byte{}
+	for i := 0; i < 4; i++ {
+		fragments = append(fragments, [][]byte{audioData(300, byte(i))})
+	}
+	init, segments := soundcloudtest.FragmentedMP4(44100, 1024, fragments)
+	otherInit, _ := soundcloudtest.FragmentedMP4(48000, 960, fragments)
+
+	for _, test := range []struct {
+		name    string
+		inits   map[int][]byte
+		succeed bool
+	}{
+		{"same init section", map[int][]byte{0: init, 2: init}, true},
+		{"different init section", map[int][]byte{0: init, 2: otherInit}, false},
+	} {
+		s := soundcloudtest.NewServer()
+		transcoding := addRemuxTrack(s, soundcloudtest.Audio{
+			Preset: "aac_160k", Protocol: "hls", MimeType: `audio/mp4; codecs="mp4a.40.2"`,
+			Segments: segments, Inits: test.inits,
+		})
+		sc := newTestAPI(t, s, nil)
+
+		buf := &bytes.Buffer{}
+		err := sc.DownloadTrack(transcoding, buf)
+		if test.succeed && err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+		} else if !test.succeed && (err == nil || buf.Len() != 0) {
+			t.Errorf("%s: expected an error and nothing to be written, received (%v) and %d bytes", test.name, err, buf.Len())
+		}
+		if count := s.RequestCount("/cdn/901/0/init/"); count != 2 {
+			t.Errorf("%s: expected (2) init section requests, received (%d)", test.name, count)
+		}
+	}
+}
+
+func TestHLSByteRanges(t *testing.T) {
+	for _, relative := range []bool{false, true} {
+		s := soundcloudtest.NewServer()
+		transcoding := addRemuxTrack(s, soundcloudtest.Audio{
+			Preset: "mp3_0_0", Protocol: "hls", MimeType: "audio/mpeg",
+			Data: audioData(1000, 7), SegmentSize: 90, ByteRanges: true, RelativeURIs: relative,
+		})
+		sc := newTestAPI(t, s, nil)
+
+		buf := &bytes.Buffer{}
+		if err := sc.DownloadTrack(transcoding, buf); err != nil {
+			t.Errorf("relative URIs (%t): %s", relative, err.Error())
+		} else if !bytes.Equal(buf.Bytes(), audioData(1000, 7)) {
+			t.Errorf("relative URIs (%t): downloaded track does not match, received %d bytes", relative, buf.Len())
+		}
+		if count := s.RequestCount("/cdn/901/0/segments"); count != 12 {
+			t.Errorf("relative URIs (%t): expected (12) byte range requests, received (%d)", relative, count)
+		}
+	}
+}
+
+func TestHLSRelativeURIs(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	transcoding := addRemuxTrack(s, soundcloudtest.Audio{
+		Preset: "mp3_0_0", Protocol: "hls", MimeType: "audio/mpeg",
+		Data: audioData(1000, 9), SegmentSize: 100, RelativeURIs: true,
+	})
+	sc := newTestAPI(t, s, nil)
+
+	buf := &bytes.Buffer{}
+	if err := sc.DownloadTrack(transcoding, buf); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if !bytes.Equal(buf.Bytes(), audioData(1000, 9)) {
+		t.Errorf("Downloaded track does not match, received %d bytes", buf.Len())
+	}
+}
@@ -0,0 +1,101 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+const longMixID = 900
+
+// addLongMix adds a track with an HLS transcoding of 100 segments
+func addLongMix(s *soundcloudtest.Server) {
+	s.AddTrack(newTrack(longMixID, newUser(8, "dj"), "long-mix", "Long Mix"),
+		soundcloudtest.Audio{Preset: "mp3_0_0", Protocol: "hls", MimeType: "audio/mpeg", Data: audioData(100*100, 4), SegmentSize: 100},
+	)
+}
+
+// blockingWriter blocks the first Write until unblock is closed
+type blockingWriter struct {
+	bytes.Buffer
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	return w.Buffer.Write(p)
+}
+
+func TestHLSWindow(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addLongMix(s)
+	sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+		options.HLSWorkers = 2
+		options.HLSWindow = 4
+	})
+
+	track, _ := s.Track(longMixID)
+	dst := &blockingWriter{unblock: make(chan struct{})}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- sc.DownloadTrack(track.Media.Transcodings[0], dst)
+	}()
+
+	// While the first segment can't be written, only the segments in the window are downloaded
+	time.Sleep(100 * time.Millisecond)
+	segmentPath := fmt.Sprintf("/cdn/%d/0/segment/", longMixID)
+	if count := s.RequestCount(segmentPath); count == 0 || count > 4 {
+		t.Errorf("Expected between (1) and (4) segment requests, received (%d)", count)
+	}
+
+	close(dst.unblock)
+	if err := <-errChan; err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if !bytes.Equal(dst.Bytes(), audioData(100*100, 4)) {
+		t.Errorf("Downloaded track does not match, received %d bytes", dst.Len())
+	}
+	if count := s.RequestCount(segmentPath); count != 100 {
+		t.Errorf("Expected (100) segment requests, received (%d)", count)
+	}
+}
+
+func TestHLSSegmentError(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addLongMix(s)
+	sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+		options.HLSWorkers = 4
+		options.HLSWindow = 8
+	})
+
+	before := runtime.NumGoroutine()
+
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: fmt.Sprintf("/cdn/%d/0/segment/42", longMixID), Status: http.StatusNotFound})
+
+	track, _ := s.Track(longMixID)
+	buf := &bytes.Buffer{}
+	err := sc.DownloadTrack(track.Media.Transcodings[0], buf)
+	if failedRequest, ok := err.(*soundcloudapi.FailedRequestError); !ok || failedRequest.Status != http.StatusNotFound {
+		t.Errorf("Expected FailedRequestError with status (%d), received: (%v)", http.StatusNotFound, err)
+	}
+
+	// Segments before the failed one were written in order
+	if buf.Len() > 42*100 || !bytes.Equal(buf.Bytes(), audioData(100*100, 4)[:buf.Len()]) {
+		t.Errorf("Expected segments before the failed one to be written, received %d bytes", buf.Len())
+	}
+
+	// Every worker stopped
+	s.CloseClientConnections()
+	time.Sleep(50 * time.Millisecond)
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Expected no goroutines to be left running, %d before and %d after", before, after)
+	}
+}
@@ -0,0 +1,71 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+// addVariants adds a track whose HLS transcoding points at a master playlist of 4 variants
+func addVariants(s *soundcloudtest.Server, relative bool) soundcloudapi.Transcoding {
+	track := s.AddTrack(newTrack(902, newUser(8, "dj"), "variants", "Variants"), soundcloudtest.Audio{
+		Preset: "aac_hq", Protocol: "hls", MimeType: "audio/mpeg", SegmentSize: 100, RelativeURIs: relative,
+		Variants: []soundcloudtest.Variant{
+			{Bandwidth: 64000, Codecs: "mp4a.40.5", Data: audioData(500, 1)},
+			{Bandwidth: 256000, Codecs: "mp4a.40.2", Data: audioData(500, 2)},
+			{Bandwidth: 96000, Codecs: "opus", Data: audioData(500, 3)},
+			{Bandwidth: 160000, Codecs: "mp4a.40.2", Data: audioData(500, 4)},
+		},
+	})
+	return track.Media.Transcodings[0]
+}
+
+func TestHLSVariantSelection(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   soundcloudapi.VariantPolicy
+		relative bool
+		seed     byte
+	}{
+		{"highest bandwidth", soundcloudapi.VariantPolicy{}, false, 2},
+		{"relative URIs", soundcloudapi.VariantPolicy{}, true, 2},
+		{"codec", soundcloudapi.VariantPolicy{Codec: "opus"}, false, 3},
+		{"max bandwidth", soundcloudapi.VariantPolicy{MaxBandwidth: 200000}, false, 4},
+		{"codec and max bandwidth", soundcloudapi.VariantPolicy{Codec: "mp4a.40.5", MaxBandwidth: 200000}, false, 1},
+	}
+
+	for _, test := range tests {
+		s := soundcloudtest.NewServer()
+		transcoding := addVariants(s, test.relative)
+		policy := test.policy
+		sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+			options.HLSVariant = policy
+		})
+
+		buf := &bytes.Buffer{}
+		if err := sc.DownloadTrack(transcoding, buf); err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+		} else if !bytes.Equal(buf.Bytes(), audioData(500, test.seed)) {
+			t.Errorf("%s: downloaded track is not the expected variant", test.name)
+		}
+	}
+}
+
+func TestHLSNoMatchingVariant(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	transcoding := addVariants(s, false)
+	sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+		options.HLSVariant = soundcloudapi.VariantPolicy{Codec: "flac"}
+	})
+
+	err := sc.DownloadTrack(transcoding, &bytes.Buffer{})
+	if errors.Cause(err) != soundcloudapi.ErrNoMatchingVariant {
+		t.Errorf("Expected ErrNoMatchingVariant, received (%v)", err)
+	}
+	if count := s.RequestCount("/cdn/902/0/variant/"); count != 0 {
+		t.Errorf("Expected no variant requests, received (%d)", count)
+	}
+}
@@ -0,0 +1,140 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+// id3Frames parses the frames of an ID3v2.4 tag at the start of data and returns them with the audio after the tag
+func id3Frames(t *testing.T, data []byte) (map[string][]byte, []byte) {
+	if len(data) < 10 || string(data[:3]) != "ID3" || data[3] != 4 {
+		t.Fatalf("Expected an ID3v2.4 header, received %q", data[:10])
+	}
+	size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+	tag, audio := data[10:10+size], data[10+size:]
+
+	frames := map[string][]byte{}
+	for len(tag) >= 10 {
+		frameSize := int(tag[4])<<21 | int(tag[5])<<14 | int(tag[6])<<7 | int(tag[7])
+		frames[string(tag[:4])] = tag[10 : 10+frameSize]
+		tag = tag[10+frameSize:]
+	}
+
+	return frames, audio
+}
+
+func TestParseTagList(t *testing.T) {
+	tags := soundcloudapi.ParseTagList(`lofi "hip hop"  chill "late night"`)
+	expected := []string{"lofi", "hip hop", "chill", "late night"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected (%q), received (%q)", expected, tags)
+	}
+}
+
+func TestID3Writer(t *testing.T) {
+	// An existing tag at the start of the audio is replaced
+	existing := soundcloudapi.ID3Tag{Title: "Old"}.Bytes()
+	audio := append(existing, audioData(1000, 5)...)
+
+	buf := &bytes.Buffer{}
+	w := soundcloudapi.NewID3Writer(buf, soundcloudapi.ID3Tag{Title: "New"})
+	for i := 0; i < len(audio); i += 3 {
+		end := i + 3
+		if end > len(audio) {
+			end = len(audio)
+		}
+		if _, err := w.Write(audio[i:end]); err != nil {
+			t.Error(err.Error())
+			return
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	frames, written := id3Frames(t, buf.Bytes())
+	if string(frames["TIT2"]) != "\x03New" {
+		t.Errorf("Expected title (New), received (%q)", frames["TIT2"])
+	}
+	if !bytes.Equal(written, audioData(1000, 5)) {
+		t.Errorf("Expected the audio to follow the tag, received %d bytes", len(written))
+	}
+}
+
+func TestDownloadTrackTagged(t *testing.T) {
+	artwork := append([]byte("\xff\xd8\xff\xe0"), audioData(200, 6)...)
+	artworkURL := server.AddImage("artworks-929590315-t500x500.jpg", artwork)
+
+	track, _ := server.Track(929590315)
+	track.ArtworkURL = artworkURL[:len(artworkURL)-len("t500x500.jpg")] + "large.jpg"
+	track.Genre = "Hip-hop & Rap"
+	track.TagList = `lofi "hip hop"`
+	track.LabelName = "Taliya"
+	track.DisplayDate = "2020-10-30T12:00:00Z"
+	track.Description = "Hold the..."
+
+	for _, transcoding := range track.Media.Transcodings {
+		buf := &bytes.Buffer{}
+		err := api.DownloadTrackWithOptions(context.Background(), transcoding, buf, soundcloudapi.DownloadOptions{Tag: &track})
+		if err != nil {
+			t.Errorf("Failed to download %s transcoding: %s", transcoding.Format.Protocol, err.Error())
+			continue
+		}
+
+		frames, audio := id3Frames(t, buf.Bytes())
+		for id, expected := range map[string]string{
+			"TIT2": "\x03Double Cheese Burger (Hold The...)",
+			"TPE1": "\x03taliya-jenkins",
+			"TCON": "\x03Hip-hop & Rap",
+			"TPUB": "\x03Taliya",
+			"TDRC": "\x032020",
+			"TXXX": "\x03Tags\x00lofi\x00hip hop",
+			"WOAF": "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the",
+			"COMM": "\x03eng\x00Hold the...",
+			"APIC": "\x03image/jpeg\x00\x03\x00" + string(artwork),
+		} {
+			if string(frames[id]) != expected {
+				t.Errorf("Expected %s frame (%q), received (%q)", id, expected, frames[id])
+			}
+		}
+
+		if !bytes.Equal(audio, audioData(4000, 1)) {
+			t.Errorf("Expected the %s audio to follow the tag, received %d bytes", transcoding.Format.Protocol, len(audio))
+		}
+	}
+}
+
+func TestDownloadTrackTaggedArtwork(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	track := s.AddTrack(newTrack(906, newUser(8, "dj"), "tagged", "Tagged"),
+		soundcloudtest.Audio{Preset: "mp3_0_0", Protocol: "progressive", MimeType: "audio/mpeg", Data: audioData(300, 1)},
+	)
+	sc := newTestAPI(t, s, nil)
+
+	// Artwork that can't be downloaded fails the download, only the t500x500 version is fetched
+	track.ArtworkURL = s.AddImage("artworks-missing-large.jpg", nil)
+	err := sc.DownloadTrackWithOptions(context.Background(), track.Media.Transcodings[0], &bytes.Buffer{}, soundcloudapi.DownloadOptions{Tag: &track})
+	if failedRequest, ok := errors.Cause(err).(*soundcloudapi.FailedRequestError); !ok || failedRequest.Status != http.StatusNotFound {
+		t.Errorf("Expected FailedRequestError with status (%d), received (%v)", http.StatusNotFound, err)
+	}
+
+	// Artwork that isn't an image is left out
+	s.AddImage("artworks-html-t500x500.jpg", []byte("<html><body>Not found</body></html>"))
+	track.ArtworkURL = s.AddImage("artworks-html-large.jpg", nil)
+	buf := &bytes.Buffer{}
+	if err := sc.DownloadTrackWithOptions(context.Background(), track.Media.Transcodings[0], buf, soundcloudapi.DownloadOptions{Tag: &track}); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if frames, audio := id3Frames(t, buf.Bytes()); frames["APIC"] != nil || !bytes.Equal(audio, audioData(300, 1)) {
+		t.Errorf("Expected the audio to be tagged without artwork, received APIC frame (%q)", frames["APIC"])
+	}
+}
@@ -1,20 +1,22 @@
 package soundcloudapi_test
 
 import (
-	"net/http"
 	"testing"
-	"time"
 
 	soundcloudapi "github.com/zackradisic/soundcloud-api"
 )
 
 func TestNew(t *testing.T) {
-	_, err := soundcloudapi.New(soundcloudapi.APIOptions{
-		HTTPClient: &http.Client{
-			Timeout: time.Second * 20,
-		},
-	})
+	options := server.APIOptions()
+	options.ClientID = ""
+
+	sc, err := soundcloudapi.New(options)
 	if err != nil {
 		t.Errorf("failed to create new API: %+v\n", err)
+		return
+	}
+
+	if sc.ClientID() != server.ClientID() {
+		t.Errorf("Expected: (%s), Received: (%s)\n", server.ClientID(), sc.ClientID())
 	}
 }
@@ -0,0 +1,125 @@
+package soundcloudapi_test
+
+import (
+	"context"
+	"testing"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+)
+
+func TestLikesPager(t *testing.T) {
+	pager := api.LikesPager(soundcloudapi.GetLikesOptions{
+		ProfileURL: "https://soundcloud.com/dasc2000",
+		Limit:      10,
+		Type:       "track",
+	})
+
+	ids := map[int64]struct{}{}
+	pages := 0
+	err := pager.ForEach(context.Background(), func(page *soundcloudapi.PaginatedQuery) error {
+		pages++
+		likes, err := page.GetLikes()
+		if err != nil {
+			return err
+		}
+		for _, like := range likes {
+			ids[like.Track.ID] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if pages != 4 {
+		t.Errorf("Expected (4) pages, received (%d)", pages)
+	}
+	if len(ids) != 35 {
+		t.Errorf("Expected (35) unique likes, received (%d)", len(ids))
+	}
+
+	if _, err := pager.Next(context.Background()); err != soundcloudapi.ErrNoMorePages {
+		t.Errorf("Expected (%v), received (%v)", soundcloudapi.ErrNoMorePages, err)
+	}
+}
+
+func TestPagerLimits(t *testing.T) {
+	pager := api.LikesPager(soundcloudapi.GetLikesOptions{
+		ProfileURL: "https://soundcloud.com/dasc2000",
+		Limit:      10,
+		Type:       "track",
+	})
+	pager.MaxPages = 2
+
+	all, err := pager.All(context.Background(), 0)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(all.Collection) != 20 {
+		t.Errorf("Expected (20) items from 2 pages, received (%d)", len(all.Collection))
+	}
+
+	pager = api.LikesPager(soundcloudapi.GetLikesOptions{
+		ProfileURL: "https://soundcloud.com/dasc2000",
+		Limit:      10,
+		Type:       "track",
+	})
+
+	all, err = pager.All(context.Background(), 25)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(all.Collection) != 25 {
+		t.Errorf("Expected (25) items, received (%d)", len(all.Collection))
+	}
+	if pager.More() {
+		t.Error("Expected pager to stop at the item limit")
+	}
+}
+
+func TestSearchPager(t *testing.T) {
+	pager := api.SearchPager(soundcloudapi.SearchOptions{
+		Query: "lofi",
+		Kind:  soundcloudapi.KindTrack,
+		Limit: 50,
+	})
+
+	all, err := pager.All(context.Background(), 0)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	tracks, err := all.GetTracks()
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(tracks) != 150 {
+		t.Errorf("Expected (150) tracks, received (%d)", len(tracks))
+	}
+}
+
+func TestSearchQueryURL(t *testing.T) {
+	page, err := api.Search(soundcloudapi.SearchOptions{
+		Query: "lofi",
+		Kind:  soundcloudapi.KindTrack,
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	// next_href doesn't contain the client ID, Search should add it
+	next, err := api.Search(soundcloudapi.SearchOptions{QueryURL: page.NextHref})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(next.Collection) == 0 {
+		t.Error("Received no results")
+	}
+}
@@ -0,0 +1,270 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+// addMixtape adds a playlist of 6 tracks: progressive, HLS, progressive, blocked, snipped and progressive
+func addMixtape(s *soundcloudtest.Server) soundcloudapi.Playlist {
+	user := newUser(20, "curator")
+
+	tracks := []soundcloudapi.Track{}
+	for i := 0; i < 6; i++ {
+		track := newTrack(int64(920+i), newUser(int64(30+i), "artist"), "track", "Track "+string(rune('A'+i)))
+		track.DurationMS = int64(60000 + i*1000)
+		audio := soundcloudtest.Audio{Protocol: "progressive", Data: audioData(200+i, byte(i))}
+		switch i {
+		case 1:
+			audio.Protocol = "hls"
+		case 3:
+			track.Policy = soundcloudapi.PolicyBlock
+		case 4:
+			audio.Snipped = true
+		}
+		tracks = append(tracks, s.AddTrack(track, audio))
+	}
+
+	return s.AddPlaylist(soundcloudapi.Playlist{
+		ID:           950,
+		Title:        "Mixtape",
+		PermalinkURL: user.PermalinkURL + "/sets/mixtape",
+		User:         user,
+		Tracks:       tracks,
+	})
+}
+
+func TestDownloadPlaylist(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	playlist := addMixtape(s)
+	sc := newTestAPI(t, s, nil)
+
+	// The media URL of the last track can't be fetched
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/media/soundcloud:tracks:925/", Status: 404})
+
+	dir := t.TempDir()
+	calls := 0
+	results, err := sc.DownloadPlaylist(playlist, dir, soundcloudapi.PlaylistDownloadOptions{
+		Workers:  3,
+		Template: `{{.Playlist.Title}}/{{.Index}} {{.Title}}.{{.Ext}}`,
+		OnResult: func(soundcloudapi.PlaylistTrackResult) { calls++ },
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(results) != 6 || calls != 6 {
+		t.Errorf("Expected (6) results and OnResult calls, received (%d) and (%d)", len(results), calls)
+		return
+	}
+
+	for i, result := range results {
+		if result.Index != i+1 || result.Track.ID != int64(920+i) {
+			t.Errorf("Expected result %d to be track (%d), received (%d) at index (%d)", i, 920+i, result.Track.ID, result.Index)
+		}
+
+		switch i {
+		case 3:
+			if errors.Cause(result.Err) != soundcloudapi.ErrBlocked {
+				t.Errorf("Expected ErrBlocked for the blocked track, received (%v)", result.Err)
+			}
+		case 4:
+			if errors.Cause(result.Err) != soundcloudapi.ErrPreviewOnly {
+				t.Errorf("Expected ErrPreviewOnly for the snipped track, received (%v)", result.Err)
+			}
+		case 5:
+			if result.Err == nil {
+				t.Error("Expected an error for the track whose media URL failed")
+			}
+		default:
+			if result.Err != nil {
+				t.Errorf("Track %d: %s", i+1, result.Err.Error())
+				continue
+			}
+			expectedPath := filepath.Join("Mixtape", string(rune('1'+i))+" Track "+string(rune('A'+i))+".mp3")
+			if result.Path != expectedPath {
+				t.Errorf("Expected path (%s), received (%s)", expectedPath, result.Path)
+			}
+			data, err := ioutil.ReadFile(filepath.Join(dir, result.Path))
+			if err != nil || !bytes.Equal(data, audioData(200+i, byte(i))) {
+				t.Errorf("Downloaded track %d does not match (%v)", i+1, err)
+			}
+		}
+	}
+
+	index, err := ioutil.ReadFile(filepath.Join(dir, soundcloudapi.DefaultPlaylistIndex))
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	expected := "#EXTM3U\n#PLAYLIST:Mixtape\n" +
+		"#EXTINF:60,artist - Track A\nMixtape/1 Track A.mp3\n" +
+		"#EXTINF:61,artist - Track B\nMixtape/2 Track B.mp3\n" +
+		"#EXTINF:62,artist - Track C\nMixtape/3 Track C.mp3\n"
+	if string(index) != expected {
+		t.Errorf("Expected index:\n%s\nreceived:\n%s", expected, index)
+	}
+}
+
+func TestDownloadPlaylistDuplicatePaths(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	playlist := addMixtape(s)
+	sc := newTestAPI(t, s, nil)
+
+	// Every track is by "artist"
+	dir := t.TempDir()
+	results, err := sc.DownloadPlaylist(playlist, dir, soundcloudapi.PlaylistDownloadOptions{Template: `{{.User.Username}}.{{.Ext}}`})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	for i, expected := range []string{"artist.mp3", "artist (2).mp3", "artist (3).mp3"} {
+		if results[i].Path != expected {
+			t.Errorf("Expected path (%s) for track %d, received (%s)", expected, i+1, results[i].Path)
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, expected))
+		if err != nil || !bytes.Equal(data, audioData(200+i, byte(i))) {
+			t.Errorf("Downloaded track %d does not match (%v)", i+1, err)
+		}
+	}
+}
+
+func TestDownloadPlaylistDuplicateTracks(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	playlist := addMixtape(s)
+	sc := newTestAPI(t, s, nil)
+
+	playlist.Tracks = append(playlist.Tracks, playlist.Tracks[0])
+	dir := t.TempDir()
+	calls := 0
+	results, err := sc.DownloadPlaylist(playlist, dir, soundcloudapi.PlaylistDownloadOptions{
+		Template: `{{.Title}}.{{.Ext}}`,
+		OnResult: func(soundcloudapi.PlaylistTrackResult) { calls++ },
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(results) != 7 || calls != 7 {
+		t.Errorf("Expected (7) results and OnResult calls, received (%d) and (%d)", len(results), calls)
+		return
+	}
+
+	if results[6].Index != 7 || results[6].Err != nil || results[6].Path != "Track A.mp3" {
+		t.Errorf("Expected the duplicate to share the path (Track A.mp3), received (%s) at index (%d) (%v)", results[6].Path, results[6].Index, results[6].Err)
+	}
+	if count := s.RequestCount("/media/soundcloud:tracks:920/"); count != 1 {
+		t.Errorf("Expected the track to be downloaded once, received (%d) media requests", count)
+	}
+}
+
+func TestDownloadPlaylistUnresolvedTracks(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	playlist := addMixtape(s)
+	sc := newTestAPI(t, s, nil)
+
+	// Tracks the playlist didn't include have no ID, and aren't duplicates of each other
+	playlist.Tracks = append(playlist.Tracks, soundcloudapi.Track{Title: "Private A"}, soundcloudapi.Track{Title: "Private B"})
+	indexes := []int{}
+	results, err := sc.DownloadPlaylist(playlist, t.TempDir(), soundcloudapi.PlaylistDownloadOptions{
+		OnResult: func(result soundcloudapi.PlaylistTrackResult) {
+			if result.Track.ID == 0 {
+				indexes = append(indexes, result.Index)
+			}
+		},
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if len(indexes) != 2 || indexes[0] == indexes[1] {
+		t.Errorf("Expected a result for each unresolved track, received (%v)", indexes)
+	}
+	for _, result := range results[6:] {
+		if result.Err == nil {
+			t.Errorf("Expected the unresolved track at index (%d) to fail", result.Index)
+		}
+	}
+	if results[6].Track.Title != "Private A" || results[7].Track.Title != "Private B" {
+		t.Errorf("Expected each unresolved track to keep its own result, received (%s, %s)", results[6].Track.Title, results[7].Track.Title)
+	}
+}
+
+func TestDownloadPlaylistTagged(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	playlist := addMixtape(s)
+	sc := newTestAPI(t, s, nil)
+
+	dir := t.TempDir()
+	var mu sync.Mutex
+	written := map[int]int64{}
+	results, err := sc.DownloadPlaylist(playlist, dir, soundcloudapi.PlaylistDownloadOptions{
+		Tag: true,
+		Progress: func(result soundcloudapi.PlaylistTrackResult, progress soundcloudapi.Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+			written[result.Index] = progress.BytesWritten
+		},
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	for i, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, result.Path))
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+		frames, audio := id3Frames(t, data)
+		if string(frames["TIT2"]) != "\x03"+result.Track.Title {
+			t.Errorf("Expected track %d to be tagged with its title, received (%q)", result.Index, frames["TIT2"])
+		}
+		if !bytes.Equal(audio, audioData(200+i, byte(i))) {
+			t.Errorf("Expected the audio of track %d to follow the tag, received %d bytes", result.Index, len(audio))
+		}
+		if written[result.Index] != int64(200+i) {
+			t.Errorf("Expected (%d) bytes written for track %d, received (%d)", 200+i, result.Index, written[result.Index])
+		}
+	}
+}
+
+func TestDownloadPlaylistCancel(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	playlist := addMixtape(s)
+	sc := newTestAPI(t, s, nil)
+
+	// The first result is the blocked track, which is reported before any download starts
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	results, err := sc.DownloadPlaylistContext(ctx, playlist, t.TempDir(), soundcloudapi.PlaylistDownloadOptions{
+		Workers:  1,
+		OnResult: func(soundcloudapi.PlaylistTrackResult) { calls++; cancel() },
+	})
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, received (%v)", err)
+	}
+	if calls != 6 {
+		t.Errorf("Expected (6) OnResult calls, received (%d)", calls)
+	}
+	for _, result := range results {
+		if result.Err == nil {
+			t.Errorf("Expected track %d not to be downloaded", result.Index)
+		}
+	}
+}
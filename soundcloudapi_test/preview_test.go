@@ -0,0 +1,115 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+// addPreviewOnly adds a Go+ track whose transcodings are all snipped
+func addPreviewOnly(s *soundcloudtest.Server) soundcloudapi.Track {
+	track := newTrack(904, newUser(8, "dj"), "go-plus", "Go+ Only")
+	track.FullDurationMS = 215000
+	return s.AddTrack(track,
+		soundcloudtest.Audio{Preset: "mp3_0_0", Protocol: "progressive", Data: audioData(300, 1), Snipped: true},
+		soundcloudtest.Audio{Preset: "mp3_0_0", Protocol: "hls", Data: audioData(300, 1), Snipped: true},
+	)
+}
+
+// checkPreviewOnlyError checks that err is a *PreviewOnlyError of the track added by addPreviewOnly
+func checkPreviewOnlyError(t *testing.T, name string, err error) {
+	if errors.Cause(err) != soundcloudapi.ErrPreviewOnly {
+		t.Errorf("%s: expected ErrPreviewOnly, received (%v)", name, err)
+		return
+	}
+
+	var previewErr *soundcloudapi.PreviewOnlyError
+	if !errors.As(err, &previewErr) {
+		t.Errorf("%s: expected a PreviewOnlyError, received (%v)", name, err)
+		return
+	}
+	if previewErr.TrackID != 904 || previewErr.DurationMS != 30000 || previewErr.FullDurationMS != 215000 {
+		t.Errorf("%s: expected track (904) with durations (30000, 215000), received (%d) with (%d, %d)",
+			name, previewErr.TrackID, previewErr.DurationMS, previewErr.FullDurationMS)
+	}
+}
+
+func TestPreviewOnly(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	track := addPreviewOnly(s)
+	sc := newTestAPI(t, s, nil)
+
+	for _, transcoding := range track.Media.Transcodings {
+		buf := &bytes.Buffer{}
+		checkPreviewOnlyError(t, "DownloadTrack "+transcoding.Format.Protocol, sc.DownloadTrack(transcoding, buf))
+		if buf.Len() != 0 {
+			t.Errorf("Expected nothing to be downloaded, received %d bytes", buf.Len())
+		}
+	}
+
+	_, err := sc.GetDownloadURL(track.PermalinkURL, "progressive")
+	checkPreviewOnlyError(t, "GetDownloadURL", err)
+
+	checkPreviewOnlyError(t, "DownloadTrackBest", sc.DownloadTrackBest(track, soundcloudapi.DefaultTranscodingPolicy(), &bytes.Buffer{}))
+
+	path := filepath.Join(t.TempDir(), "preview.mp3")
+	checkPreviewOnlyError(t, "DownloadTrackToFile", sc.DownloadTrackToFile(track.Media.Transcodings[0], path))
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Error("Expected no file to be created")
+	}
+}
+
+func TestAllowPreviews(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	track := addPreviewOnly(s)
+	sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+		options.AllowPreviews = true
+	})
+
+	buf := &bytes.Buffer{}
+	if err := sc.DownloadTrack(track.Media.Transcodings[0], buf); err != nil {
+		t.Error(err.Error())
+	} else if !bytes.Equal(buf.Bytes(), audioData(300, 1)) {
+		t.Error("Downloaded preview does not match")
+	}
+
+	if _, err := sc.GetDownloadURL(track.PermalinkURL, "progressive"); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestPreviewPolicy(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	track := addPreviewOnly(s)
+	sc := newTestAPI(t, s, nil)
+
+	// The policy decides whether previews are downloaded, without APIOptions.AllowPreviews
+	policy := soundcloudapi.DefaultTranscodingPolicy()
+	policy.AllowSnipped = true
+	buf := &bytes.Buffer{}
+	if err := sc.DownloadTrackBest(track, policy, buf); err != nil {
+		t.Error(err.Error())
+	} else if !bytes.Equal(buf.Bytes(), audioData(300, 1)) {
+		t.Error("Downloaded preview does not match")
+	}
+}
+
+func TestPreviewOnlyLookupError(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	track := addPreviewOnly(s)
+	sc := newTestAPI(t, s, nil)
+
+	// The durations can't be looked up, but the track is still a preview
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/tracks", Status: http.StatusInternalServerError})
+	err := sc.DownloadTrack(track.Media.Transcodings[0], &bytes.Buffer{})
+	var previewErr *soundcloudapi.PreviewOnlyError
+	if !errors.As(err, &previewErr) || previewErr.TrackID != 904 {
+		t.Errorf("Expected a PreviewOnlyError of track (904), received (%v)", err)
+	}
+}
@@ -0,0 +1,100 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func TestDownloadProgress(t *testing.T) {
+	track, _ := server.Track(929590315)
+
+	for _, transcoding := range track.Media.Transcodings {
+		reports := []soundcloudapi.Progress{}
+		err := api.DownloadTrackWithOptions(context.Background(), transcoding, &bytes.Buffer{}, soundcloudapi.DownloadOptions{
+			Progress: func(progress soundcloudapi.Progress) {
+				reports = append(reports, progress)
+			},
+		})
+		if err != nil {
+			t.Errorf("Failed to download %s transcoding: %s", transcoding.Format.Protocol, err.Error())
+			continue
+		}
+
+		if len(reports) == 0 {
+			t.Errorf("Expected progress to be reported for %s transcoding", transcoding.Format.Protocol)
+			continue
+		}
+
+		for i := 1; i < len(reports); i++ {
+			if reports[i].BytesWritten < reports[i-1].BytesWritten {
+				t.Errorf("Expected BytesWritten to increase, received (%d) after (%d)", reports[i].BytesWritten, reports[i-1].BytesWritten)
+			}
+		}
+
+		last := reports[len(reports)-1]
+		if last.BytesWritten != 4000 {
+			t.Errorf("Expected (4000) bytes written, received (%d)", last.BytesWritten)
+		}
+
+		switch transcoding.Format.Protocol {
+		case "progressive":
+			if last.TotalBytes != 4000 {
+				t.Errorf("Expected TotalBytes (4000), received (%d)", last.TotalBytes)
+			}
+		case "hls":
+			// 4000 bytes split into 1024 byte segments of 10s
+			if last.TotalSegments != 4 || last.SegmentsCompleted != 4 {
+				t.Errorf("Expected (4/4) segments, received (%d/%d)", last.SegmentsCompleted, last.TotalSegments)
+			}
+			if last.TotalDuration != 40*time.Second || last.Duration != 40*time.Second {
+				t.Errorf("Expected (40s/40s) duration, received (%s/%s)", last.Duration, last.TotalDuration)
+			}
+			if last.TotalBytes != -1 {
+				t.Errorf("Expected unknown TotalBytes, received (%d)", last.TotalBytes)
+			}
+		}
+	}
+}
+
+// slowWriter sleeps before every write once fast bytes were written
+type slowWriter struct {
+	fast    int
+	written int
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	if w.written >= w.fast {
+		time.Sleep(10 * time.Millisecond)
+	}
+	w.written += len(p)
+	return len(p), nil
+}
+
+func TestDownloadProgressThroughput(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	track := s.AddTrack(newTrack(905, newUser(8, "dj"), "slow", "Slow"),
+		soundcloudtest.Audio{Preset: "mp3_0_0", Protocol: "hls", MimeType: "audio/mpeg", Data: audioData(40*1000, 1), SegmentSize: 1000},
+	)
+	sc := newTestAPI(t, s, nil)
+
+	// Half of the segments are written quickly, the rest slowly
+	var last soundcloudapi.Progress
+	err := sc.DownloadTrackWithOptions(context.Background(), track.Media.Transcodings[0], &slowWriter{fast: 20 * 1000}, soundcloudapi.DownloadOptions{
+		Progress: func(progress soundcloudapi.Progress) { last = progress },
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	// The throughput of the last segments is about half the average
+	average := float64(last.BytesWritten) / last.Elapsed.Seconds()
+	if last.BytesPerSecond <= 0 || last.BytesPerSecond > 0.75*average {
+		t.Errorf("Expected the throughput of the last segments to be below (%.0f), received (%.0f)", 0.75*average, last.BytesPerSecond)
+	}
+}
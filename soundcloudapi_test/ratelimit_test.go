@@ -0,0 +1,86 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := soundcloudapi.NewRateLimiter(20, 2)
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Error(err.Error())
+			return
+		}
+	}
+
+	// 2 requests are allowed by the burst and the other 4 take 50ms each, minus some slack
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected 6 requests to take at least 150ms, took %s", elapsed)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	limiter := soundcloudapi.NewRateLimiter(0.1, 1)
+	limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected (%v), received (%v)", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Wait to return when the context is done, returned after %s", elapsed)
+	}
+}
+
+func TestSharedRateLimiter(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	defer s.Close()
+	addFixtures(s)
+
+	options := s.APIOptions()
+	options.APIRateLimiter = soundcloudapi.NewRateLimiter(0.1, 2)
+	options.MediaRateLimiter = soundcloudapi.NewRateLimiter(1000, 100)
+
+	sc1, err := soundcloudapi.New(options)
+	if err != nil {
+		t.Errorf("failed to create new API: %+v\n", err)
+		return
+	}
+	sc2, err := soundcloudapi.New(options)
+	if err != nil {
+		t.Errorf("failed to create new API: %+v\n", err)
+		return
+	}
+
+	// The media fetches of an HLS download aren't limited by the API's budget
+	track, _ := s.Track(929590315)
+	if err := sc1.DownloadTrack(track.Media.Transcodings[0], &bytes.Buffer{}); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	// sc1 used the first API request of the shared budget for the media URL, so sc2 can make one more
+	_, err = sc2.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = sc1.GetTrackInfoContext(ctx, soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+	if err == nil {
+		t.Error("Expected the shared API budget to be exhausted")
+	}
+}
@@ -0,0 +1,72 @@
+package soundcloudapi_test
+
+import (
+	"sync"
+	"testing"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func TestAutoRefreshClientID(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	defer s.Close()
+	addFixtures(s)
+
+	options := s.APIOptions()
+	options.AutoRefreshClientID = true
+	sc, err := soundcloudapi.New(options)
+	if err != nil {
+		t.Errorf("failed to create new API: %+v\n", err)
+		return
+	}
+
+	s.RotateClientID("rotatedclientid")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sc.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected request to be retried with a new client ID, received: %s", err.Error())
+			return
+		}
+	}
+
+	if sc.ClientID() != "rotatedclientid" {
+		t.Errorf("Expected: (%s), Received: (%s)\n", "rotatedclientid", sc.ClientID())
+	}
+
+	if count := s.RequestCount("/web"); count != 1 {
+		t.Errorf("Expected the client ID to be fetched once, fetched (%d) times", count)
+	}
+}
+
+func TestAutoRefreshClientIDDisabled(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	defer s.Close()
+	addFixtures(s)
+
+	sc, err := soundcloudapi.New(s.APIOptions())
+	if err != nil {
+		t.Errorf("failed to create new API: %+v\n", err)
+		return
+	}
+
+	s.RotateClientID("rotatedclientid")
+
+	_, err = sc.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+	if failedRequest, ok := err.(*soundcloudapi.FailedRequestError); !ok || failedRequest.Status != 401 {
+		t.Errorf("Expected FailedRequestError with status (%d), received: (%v)", 401, err)
+	}
+}
@@ -0,0 +1,248 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+// addRemuxTrack adds a track with audio as its only transcoding, and returns the transcoding
+func addRemuxTrack(s *soundcloudtest.Server, audio soundcloudtest.Audio) soundcloudapi.Transcoding {
+	track := s.AddTrack(newTrack(901, newUser(8, "dj"), "remux", "Remux"), audio)
+	return track.Media.Transcodings[0]
+}
+
+// mp4Children parses the boxes in data into a map of type to payloads
+func mp4Children(t *testing.T, data []byte) ([]string, map[string][]byte) {
+	order := []string{}
+	boxes := map[string][]byte{}
+	for len(data) > 0 {
+		size := binary.BigEndian.Uint32(data[0:4])
+		if size < 8 || int(size) > len(data) {
+			t.Fatalf("Invalid MP4 box size %d", size)
+		}
+		typ := string(data[4:8])
+		order = append(order, typ)
+		boxes[typ] = data[8:size]
+		data = data[size:]
+	}
+	return order, boxes
+}
+
+func mp4Path(t *testing.T, data []byte, path ...string) []byte {
+	for _, typ := range path {
+		_, boxes := mp4Children(t, data)
+		var ok bool
+		if data, ok = boxes[typ]; !ok {
+			t.Fatalf("MP4 has no %s box", typ)
+		}
+	}
+	return data
+}
+
+func TestRemuxM4A(t *testing.T) {
+	fragments := [][][]byte{}
+	samples := [][]byte{}
+	for i := 0; i < 5; i++ {
+		fragment := [][]byte{}
+		for j := 0; j < 43; j++ {
+			sample := audioData(100+i*10+j, byte(i*43+j))
+			fragment = append(fragment, sample)
+			samples = append(samples, sample)
+		}
+		fragments = append(fragments, fragment)
+	}
+	init, segments := soundcloudtest.FragmentedMP4(44100, 1024, fragments)
+	segments[0] = append(init, segments[0]...)
+
+	s := soundcloudtest.NewServer()
+	transcoding := addRemuxTrack(s, soundcloudtest.Audio{Preset: "aac_160k", Protocol: "hls", MimeType: `audio/mp4; codecs="mp4a.40.2"`, Segments: segments})
+	sc := newTestAPI(t, s, nil)
+
+	buf := &bytes.Buffer{}
+	if err := sc.DownloadTrack(transcoding, buf); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	data := buf.Bytes()
+
+	order, _ := mp4Children(t, data)
+	if len(order) != 3 || order[0] != "ftyp" || order[1] != "moov" || order[2] != "mdat" {
+		t.Errorf("Expected (ftyp, moov, mdat) boxes, received (%v)", order)
+		return
+	}
+
+	if _, moov := mp4Children(t, mp4Path(t, data, "moov")); moov["mvex"] != nil {
+		t.Error("Expected the remuxed file not to be fragmented")
+	}
+
+	// 215 samples of 1024 / 44100 seconds
+	mdhd := mp4Path(t, data, "moov", "trak", "mdia", "mdhd")
+	if duration := binary.BigEndian.Uint32(mdhd[16:20]); duration != 215*1024 {
+		t.Errorf("Expected mdhd duration (%d), received (%d)", 215*1024, duration)
+	}
+	mvhd := mp4Path(t, data, "moov", "mvhd")
+	if duration := binary.BigEndian.Uint32(mvhd[16:20]); duration != 215*1024*1000/44100 {
+		t.Errorf("Expected mvhd duration (%d), received (%d)", 215*1024*1000/44100, duration)
+	}
+
+	stbl := mp4Path(t, data, "moov", "trak", "mdia", "minf", "stbl")
+	_, tables := mp4Children(t, stbl)
+	stts := tables["stts"]
+	if binary.BigEndian.Uint32(stts[4:8]) != 1 || binary.BigEndian.Uint32(stts[8:12]) != 215 || binary.BigEndian.Uint32(stts[12:16]) != 1024 {
+		t.Errorf("Expected a single stts entry of 215 samples, received (%x)", stts)
+	}
+
+	stsz := tables["stsz"]
+	if count := binary.BigEndian.Uint32(stsz[8:12]); count != 215 {
+		t.Errorf("Expected (215) samples, received (%d)", count)
+		return
+	}
+	offset := int(binary.BigEndian.Uint32(tables["stco"][8:12]))
+	for i, sample := range samples {
+		size := int(binary.BigEndian.Uint32(stsz[12+4*i:]))
+		if size != len(sample) || !bytes.Equal(data[offset:offset+size], sample) {
+			t.Errorf("Sample %d does not match", i)
+			return
+		}
+		offset += size
+	}
+	if offset != len(data) {
+		t.Errorf("Expected the samples to end the file, %d bytes left", len(data)-offset)
+	}
+}
+
+type oggPage struct {
+	headerType byte
+	granule    int64
+	serial     uint32
+	sequence   uint32
+	body       []byte
+}
+
+func parseOggPages(t *testing.T, data []byte) []oggPage {
+	pages := []oggPage{}
+	for len(data) > 0 {
+		if len(data) < 27 || string(data[:4]) != "OggS" {
+			t.Fatalf("Invalid Ogg page")
+		}
+		segments := int(data[26])
+		bodySize := 0
+		for _, l := range data[27 : 27+segments] {
+			bodySize += int(l)
+		}
+		size := 27 + segments + bodySize
+
+		page := append([]byte{}, data[:size]...)
+		crc := binary.LittleEndian.Uint32(page[22:26])
+		copy(page[22:26], []byte{0, 0, 0, 0})
+		if soundcloudtest.OggCRC(page) != crc {
+			t.Fatalf("Invalid checksum of Ogg page %d", len(pages))
+		}
+
+		pages = append(pages, oggPage{
+			headerType: data[5],
+			granule:    int64(binary.LittleEndian.Uint64(data[6:14])),
+			serial:     binary.LittleEndian.Uint32(data[14:18]),
+			sequence:   binary.LittleEndian.Uint32(data[18:22]),
+			body:       data[27+segments : size],
+		})
+		data = data[size:]
+	}
+	return pages
+}
+
+func TestRemuxM4AInvalidSample(t *testing.T) {
+	// The fields of the trun are version and flags, sample_count, data_offset and the sample sizes
+	for _, test := range []struct {
+		name  string
+		field int // index of the trun field to corrupt
+		value uint32
+	}{
+		{"sample past the end of the mdat", 5, 101},
+		{"data offset outside of the mdat", 2, 0},
+	} {
+		init, segments := soundcloudtest.FragmentedMP4(44100, 1024, [][][]byte{{audioData(100, 1), audioData(100, 2), audioData(100, 3)}})
+		segment := append(init, segments[0]...)
+		trun := bytes.Index(segment, []byte("trun")) + 4
+		binary.BigEndian.PutUint32(segment[trun+4*test.field:], test.value)
+
+		s := soundcloudtest.NewServer()
+		transcoding := addRemuxTrack(s, soundcloudtest.Audio{Preset: "aac_160k", Protocol: "hls", MimeType: `audio/mp4; codecs="mp4a.40.2"`, Segments: [][]byte{segment}})
+		sc := newTestAPI(t, s, nil)
+
+		buf := &bytes.Buffer{}
+		if err := sc.DownloadTrack(transcoding, buf); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%s: expected nothing to be written, received %d bytes", test.name, buf.Len())
+		}
+	}
+}
+
+func TestRemuxOggOpus(t *testing.T) {
+	// Every segment is a separate Ogg stream, like SoundCloud serves them
+	segments := [][]byte{}
+	packets := [][]byte{}
+	for i := 0; i < 4; i++ {
+		segmentPackets := [][]byte{}
+		for j := 0; j < 10; j++ {
+			packet := audioData(200+i*100+j, byte(i*10+j))
+			segmentPackets = append(segmentPackets, packet)
+			packets = append(packets, packet)
+		}
+		segments = append(segments, soundcloudtest.OggOpus(uint32(1000+i), segmentPackets, 960))
+	}
+
+	s := soundcloudtest.NewServer()
+	transcoding := addRemuxTrack(s, soundcloudtest.Audio{Preset: "opus_0_0", Protocol: "hls", MimeType: `audio/ogg; codecs="opus"`, Segments: segments})
+	sc := newTestAPI(t, s, nil)
+
+	buf := &bytes.Buffer{}
+	if err := sc.DownloadTrack(transcoding, buf); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	pages := parseOggPages(t, buf.Bytes())
+	if len(pages) != 2+len(packets) {
+		t.Errorf("Expected (%d) pages, received (%d)", 2+len(packets), len(pages))
+		return
+	}
+
+	if !bytes.HasPrefix(pages[0].body, []byte("OpusHead")) || !bytes.HasPrefix(pages[1].body, []byte("OpusTags")) {
+		t.Error("Expected the stream to start with the Opus headers")
+	}
+
+	for i, page := range pages {
+		if page.serial != 1000 {
+			t.Errorf("Expected serial (1000) for page %d, received (%d)", i, page.serial)
+		}
+		if page.sequence != uint32(i) {
+			t.Errorf("Expected sequence (%d) for page %d, received (%d)", i, i, page.sequence)
+		}
+
+		expectedType := byte(0)
+		if i == 0 {
+			expectedType = 0x02
+		} else if i == len(pages)-1 {
+			expectedType = 0x04
+		}
+		if page.headerType != expectedType {
+			t.Errorf("Expected header type (%d) for page %d, received (%d)", expectedType, i, page.headerType)
+		}
+
+		if i >= 2 {
+			if expected := int64((i - 1) * 960); page.granule != expected {
+				t.Errorf("Expected granule position (%d) for page %d, received (%d)", expected, i, page.granule)
+			}
+			if !bytes.Equal(page.body, packets[i-2]) {
+				t.Errorf("Packet of page %d does not match", i)
+			}
+		}
+	}
+}
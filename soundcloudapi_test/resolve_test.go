@@ -0,0 +1,35 @@
+package soundcloudapi_test
+
+import (
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		url  string
+		kind string
+		id   int64
+	}{
+		{"https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the", "track", 929590315},
+		{"https://soundcloud.com/ilyanaazman/sets/latenightlofi", "playlist", 10},
+		{"https://soundcloud.com/jaiseanforever", "user", 2},
+	}
+
+	for _, test := range tests {
+		resource, err := api.Resolve(test.url)
+		if err != nil {
+			t.Errorf("%s: %s", test.url, err.Error())
+			continue
+		}
+		if resource.Kind != test.kind || resource.ID() != test.id {
+			t.Errorf("%s: expected %s (%d), received %s (%d)", test.url, test.kind, test.id, resource.Kind, resource.ID())
+		}
+		if resource.PermalinkURL() != test.url {
+			t.Errorf("%s: permalink URL mismatch, received (%s)", test.url, resource.PermalinkURL())
+		}
+	}
+
+	if _, err := api.Resolve("https://soundcloud.com/nobody-here"); err == nil {
+		t.Error("Expected an error for a URL that doesn't resolve")
+	}
+}
@@ -0,0 +1,124 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func TestRetryTransientStatus(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+		options.RetryPolicy = soundcloudapi.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	})
+
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/resolve", Status: http.StatusServiceUnavailable, Times: 2})
+
+	_, err := sc.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+	if err != nil {
+		t.Errorf("Expected request to be retried, received: %s", err.Error())
+	}
+
+	if count := s.RequestCount("/resolve"); count != 3 {
+		t.Errorf("Expected (3) requests, received (%d)", count)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+		options.RetryPolicy = soundcloudapi.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	})
+
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/resolve", Status: http.StatusBadGateway})
+
+	_, err := sc.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+	if failedRequest, ok := err.(*soundcloudapi.FailedRequestError); !ok || failedRequest.Status != http.StatusBadGateway {
+		t.Errorf("Expected FailedRequestError with status (%d), received: (%v)", http.StatusBadGateway, err)
+	}
+
+	if count := s.RequestCount("/resolve"); count != 2 {
+		t.Errorf("Expected (2) requests, received (%d)", count)
+	}
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+		options.RetryPolicy = soundcloudapi.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	})
+
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/resolve", Status: http.StatusNotFound, Times: 1})
+
+	_, err := sc.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+	if err == nil {
+		t.Error("Expected 404 not to be retried")
+	}
+
+	if count := s.RequestCount("/resolve"); count != 1 {
+		t.Errorf("Expected (1) request, received (%d)", count)
+	}
+}
+
+func TestRetryDisabled(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, nil)
+
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/resolve", Status: http.StatusTooManyRequests, Times: 1})
+
+	_, err := sc.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+	if err == nil {
+		t.Error("Expected request not to be retried by the zero RetryPolicy")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+		options.RetryPolicy = soundcloudapi.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	})
+
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/resolve", Status: http.StatusTooManyRequests, RetryAfter: time.Second, Times: 1})
+
+	start := time.Now()
+	_, err := sc.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{URL: "https://soundcloud.com/taliya-jenkins/double-cheese-burger-hold-the"})
+	if err != nil {
+		t.Errorf("Expected request to be retried, received: %s", err.Error())
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected Retry-After to delay the retry by 1s, retried after %s", elapsed)
+	}
+}
+
+func TestRetryMediaDownload(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	addFixtures(s)
+	sc := newTestAPI(t, s, func(options *soundcloudapi.APIOptions) {
+		options.RetryPolicy = soundcloudapi.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	})
+
+	track, _ := s.Track(929590315)
+	for _, transcoding := range track.Media.Transcodings {
+		s.InjectFault(soundcloudtest.Fault{PathPrefix: "/cdn/", Status: http.StatusServiceUnavailable, Times: 2})
+
+		buf := &bytes.Buffer{}
+		if err := sc.DownloadTrack(transcoding, buf); err != nil {
+			t.Errorf("Expected %s download to be retried, received: %s", transcoding.Format.Protocol, err.Error())
+			continue
+		}
+
+		if !bytes.Equal(buf.Bytes(), audioData(4000, 1)) {
+			t.Errorf("Expected %s download to return the track's audio", transcoding.Format.Protocol)
+		}
+	}
+}
@@ -31,3 +31,23 @@ func TestSearch(t *testing.T) {
 		}
 	}
 }
+
+func TestSearchUsers(t *testing.T) {
+	response, err := api.Search(soundcloudapi.SearchOptions{
+		Query: "jaisean",
+		Kind:  soundcloudapi.KindUser,
+	})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	users, err := response.GetUsers()
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(users) != 1 || users[0].ID != 2 {
+		t.Errorf("Expected user (2), received (%v)", users)
+	}
+}
@@ -1,6 +1,7 @@
 package soundcloudapi_test
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -8,21 +9,168 @@ import (
 	"time"
 
 	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
 )
 
+// api is backed by server, tests that need to talk to soundcloud.com should call liveAPI
 var api *soundcloudapi.API
+var server *soundcloudtest.Server
 
 func TestMain(m *testing.M) {
+	server = soundcloudtest.NewServer()
+	addFixtures(server)
+
 	var err error
-	api, err = soundcloudapi.New(soundcloudapi.APIOptions{
+	api, err = soundcloudapi.New(server.APIOptions())
+	if err != nil {
+		log.Fatalf("failed to create new API: %+v\n", err)
+	}
+
+	code := m.Run()
+	server.Close()
+	os.Exit(code)
+}
+
+// newTestAPI returns an API backed by s, and closes s when the test ends. If configure isn't nil,
+// it changes the options before the API is created.
+func newTestAPI(t *testing.T, s *soundcloudtest.Server, configure func(options *soundcloudapi.APIOptions)) *soundcloudapi.API {
+	t.Helper()
+	t.Cleanup(s.Close)
+
+	options := s.APIOptions()
+	if configure != nil {
+		configure(&options)
+	}
+	sc, err := soundcloudapi.New(options)
+	if err != nil {
+		t.Fatalf("failed to create new API: %+v\n", err)
+	}
+
+	return sc
+}
+
+// liveAPI skips the test unless SOUNDCLOUD_LIVE_TESTS is set, and otherwise returns
+// an API that talks to soundcloud.com
+func liveAPI(t *testing.T) *soundcloudapi.API {
+	requireLive(t)
+
+	sc, err := soundcloudapi.New(soundcloudapi.APIOptions{
 		HTTPClient: &http.Client{
 			Timeout: time.Second * 20,
 		},
 	})
 	if err != nil {
-		log.Fatalf("failed to create new API: %+v\n", err)
+		t.Fatalf("failed to create new API: %+v\n", err)
 	}
 
-	code := m.Run()
-	os.Exit(code)
+	return sc
+}
+
+// requireLive skips the test unless SOUNDCLOUD_LIVE_TESTS is set
+func requireLive(t *testing.T) {
+	if os.Getenv("SOUNDCLOUD_LIVE_TESTS") == "" {
+		t.Skip("set SOUNDCLOUD_LIVE_TESTS to run tests against soundcloud.com")
+	}
+}
+
+// audioData returns n bytes of fake audio
+func audioData(n int, seed byte) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i) ^ seed
+	}
+	return data
+}
+
+func newUser(id int64, permalink string) soundcloudapi.User {
+	return soundcloudapi.User{
+		ID:           id,
+		Kind:         "user",
+		Username:     permalink,
+		PermalinkURL: "https://soundcloud.com/" + permalink,
+	}
+}
+
+func newTrack(id int64, user soundcloudapi.User, permalink string, title string) soundcloudapi.Track {
+	return soundcloudapi.Track{
+		ID:           id,
+		Kind:         "track",
+		Title:        title,
+		Permalink:    permalink,
+		PermalinkURL: user.PermalinkURL + "/" + permalink,
+		DurationMS:   30000,
+		Streamable:   true,
+		User:         user,
+	}
+}
+
+func addFixtures(s *soundcloudtest.Server) {
+	taliya := newUser(1, "taliya-jenkins")
+	track := newTrack(929590315, taliya, "double-cheese-burger-hold-the", "Double Cheese Burger (Hold The...)")
+	track.Downloadable = true
+	track.HasDownloadsLeft = true
+	s.AddTrack(track,
+		soundcloudtest.Audio{Preset: "mp3_0_0", Protocol: "hls", MimeType: "audio/mpeg", Data: audioData(4000, 1)},
+		soundcloudtest.Audio{Preset: "mp3_0_0", Protocol: "progressive", MimeType: "audio/mpeg", Data: audioData(4000, 1)},
+	)
+	s.SetOriginal(track.ID, audioData(8000, 2))
+
+	redbone := newTrack(122144511, newUser(4, "childish-gambino"), "redbone", "Redbone")
+	s.AddTrack(redbone,
+		soundcloudtest.Audio{Preset: "mp3_0_0", Protocol: "hls", MimeType: "audio/mpeg", Data: audioData(3000, 3)},
+	)
+
+	lofi := newUser(5, "ilyanaazman")
+	for _, fixture := range []struct {
+		id        int64
+		permalink string
+		count     int
+	}{
+		{10, "latenightlofi", 30},
+		{11, "best-of-mrrevillz", 120},
+	} {
+		playlist := soundcloudapi.Playlist{
+			ID:           fixture.id,
+			Kind:         "playlist",
+			Title:        fixture.permalink,
+			PermalinkURL: lofi.PermalinkURL + "/sets/" + fixture.permalink,
+			User:         lofi,
+		}
+		for i := 0; i < fixture.count; i++ {
+			id := playlist.ID*1000 + int64(i)
+			playlist.Tracks = append(playlist.Tracks, s.AddTrack(newTrack(id, lofi, fmt.Sprintf("lofi-%d", id), fmt.Sprintf("Lofi %d", id))))
+		}
+		s.AddPlaylist(playlist)
+	}
+
+	for _, fixture := range []struct {
+		user   soundcloudapi.User
+		tracks int
+		sets   int
+	}{
+		{newUser(2, "jaiseanforever"), 12, 2},
+		{newUser(3, "ibr"), 0, 11},
+		{newUser(304506184, "someone"), 15, 0},
+		{newUser(6, "dasc2000"), 35, 5},
+	} {
+		s.AddUser(fixture.user)
+		for i := 0; i < fixture.tracks; i++ {
+			liked := s.AddTrack(newTrack(fixture.user.ID*100+int64(i), newUser(7, "artist"), fmt.Sprintf("liked-%d", i), fmt.Sprintf("Liked %d by %s", i, fixture.user.Username)))
+			s.AddLike(fixture.user.ID, soundcloudapi.Like{
+				CreatedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Duration(i) * time.Hour).Format(time.RFC3339),
+				Track:     liked,
+			})
+		}
+		for i := 0; i < fixture.sets; i++ {
+			liked := s.AddPlaylist(soundcloudapi.Playlist{
+				ID:           fixture.user.ID*100 + 50 + int64(i),
+				Title:        fmt.Sprintf("Set %d liked by %s", i, fixture.user.Username),
+				PermalinkURL: fmt.Sprintf("https://soundcloud.com/artist/sets/liked-%d-%d", fixture.user.ID, i),
+			})
+			s.AddLike(fixture.user.ID, soundcloudapi.Like{
+				CreatedAt: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Duration(i) * time.Hour).Format(time.RFC3339),
+				Playlist:  liked,
+			})
+		}
+	}
 }
@@ -0,0 +1,142 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+const syncUserID = 40
+
+func addSyncLike(s *soundcloudtest.Server, id int64, likedAt time.Time) {
+	track := s.AddTrack(newTrack(id, newUser(41, "artist"), fmt.Sprintf("sync-%d", id), fmt.Sprintf("Sync %d", id)),
+		soundcloudtest.Audio{Protocol: "progressive", Data: audioData(100, byte(id))},
+	)
+	s.PrependLike(syncUserID, soundcloudapi.Like{CreatedAt: likedAt.Format(time.RFC3339), Track: track})
+}
+
+func syncedIDs(tracks []soundcloudapi.SyncedTrack) []int64 {
+	ids := []int64{}
+	for _, track := range tracks {
+		ids = append(ids, track.ID)
+	}
+	return ids
+}
+
+func TestSyncLikes(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	s.AddUser(newUser(syncUserID, "syncer"))
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		addSyncLike(s, int64(960+i), start.Add(time.Duration(i)*time.Hour))
+	}
+
+	sc := newTestAPI(t, s, nil)
+
+	dir := t.TempDir()
+	likes := soundcloudapi.GetLikesOptions{ID: syncUserID, Limit: 2}
+	summary, err := sc.SyncLikes(likes, dir, soundcloudapi.SyncOptions{})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(summary.Added) != 4 || len(summary.Failed) != 0 {
+		t.Errorf("Expected (4) tracks to be added, received (%v) (%v)", syncedIDs(summary.Added), summary.Failed)
+		return
+	}
+	for _, added := range summary.Added {
+		data, err := ioutil.ReadFile(filepath.Join(dir, added.Path))
+		if err != nil || !bytes.Equal(data, audioData(100, byte(added.ID))) {
+			t.Errorf("Expected track %d to be downloaded to %s (%v)", added.ID, added.Path, err)
+		}
+	}
+
+	// Only the likes since the last sync are walked
+	addSyncLike(s, 964, start.Add(5*time.Hour))
+	requests := s.RequestCount("/users/")
+	summary, err = sc.SyncLikes(likes, dir, soundcloudapi.SyncOptions{})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if ids := syncedIDs(summary.Added); len(ids) != 1 || ids[0] != 964 {
+		t.Errorf("Expected track 964 to be added, received (%v)", ids)
+	}
+	if count := s.RequestCount("/users/") - requests; count != 1 {
+		t.Errorf("Expected (1) page of likes to be fetched, received (%d)", count)
+	}
+
+	// Unliked tracks are moved to the trash
+	s.RemoveLike(syncUserID, 961)
+	summary, err = sc.SyncLikes(likes, dir, soundcloudapi.SyncOptions{TrashUnliked: true})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(summary.Added) != 0 || len(summary.Removed) != 1 || summary.Removed[0].ID != 961 {
+		t.Errorf("Expected track 961 to be removed, received (%v) (%v)", syncedIDs(summary.Added), syncedIDs(summary.Removed))
+		return
+	}
+	removed := summary.Removed[0].Path
+	if _, err := os.Stat(filepath.Join(dir, removed)); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, received (%v)", removed, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, soundcloudapi.DefaultSyncTrashDir, removed)); err != nil {
+		t.Errorf("Expected %s to be in the trash, received (%v)", removed, err)
+	}
+}
+
+func TestSyncLikesResume(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	s.AddUser(newUser(syncUserID, "syncer"))
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		addSyncLike(s, int64(970+i), start.Add(time.Duration(i)*time.Hour))
+	}
+	s.InjectFault(soundcloudtest.Fault{PathPrefix: "/media/soundcloud:tracks:971/", Status: 404})
+
+	sc := newTestAPI(t, s, nil)
+
+	dir := t.TempDir()
+	likes := soundcloudapi.GetLikesOptions{ID: syncUserID, Limit: 2}
+	summary, err := sc.SyncLikes(likes, dir, soundcloudapi.SyncOptions{})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(summary.Added) != 2 || len(summary.Failed) != 1 || summary.Failed[0].Track.ID != 971 {
+		t.Errorf("Expected track 971 to fail, received (%v) (%v)", syncedIDs(summary.Added), summary.Failed)
+		return
+	}
+
+	// The failed track is retried, and the tracks already synced aren't downloaded again
+	s.ClearFaults()
+	requests := s.RequestCount("/cdn/")
+	summary, err = sc.SyncLikes(likes, dir, soundcloudapi.SyncOptions{})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if ids := syncedIDs(summary.Added); len(ids) != 1 || ids[0] != 971 || len(summary.Failed) != 0 {
+		t.Errorf("Expected track 971 to be added, received (%v) (%v)", ids, summary.Failed)
+	}
+	if count := s.RequestCount("/cdn/") - requests; count != 1 {
+		t.Errorf("Expected (1) track to be downloaded, received (%d)", count)
+	}
+
+	summary, err = sc.SyncLikes(likes, dir, soundcloudapi.SyncOptions{})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(summary.Added) != 0 || len(summary.Failed) != 0 {
+		t.Errorf("Expected nothing to sync, received (%v) (%v)", syncedIDs(summary.Added), summary.Failed)
+	}
+}
@@ -0,0 +1,65 @@
+package soundcloudapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func TestTrackPolicyDecoding(t *testing.T) {
+	var track soundcloudapi.Track
+	if err := json.Unmarshal([]byte(`{"policy":"BLOCK","monetization_model":"SUB_HIGH_TIER"}`), &track); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if track.Policy != soundcloudapi.PolicyBlock {
+		t.Errorf("Expected policy (%s), received (%s)", soundcloudapi.PolicyBlock, track.Policy)
+	}
+	if track.MonetizationModel != soundcloudapi.MonetizationSubHighTier {
+		t.Errorf("Expected monetization model (%s), received (%s)", soundcloudapi.MonetizationSubHighTier, track.MonetizationModel)
+	}
+}
+
+func TestTrackPlayability(t *testing.T) {
+	s := soundcloudtest.NewServer()
+
+	tests := []struct {
+		policy   soundcloudapi.TrackPolicy
+		snipped  bool
+		playable bool
+		blocked  bool
+		isSnip   bool
+	}{
+		{soundcloudapi.PolicyAllow, false, true, false, false},
+		{soundcloudapi.PolicyMonetize, false, true, false, false},
+		{soundcloudapi.PolicySnip, true, false, false, true},
+		{soundcloudapi.PolicyBlock, false, false, true, false},
+		{"", true, false, false, true},
+	}
+
+	for i, test := range tests {
+		track := newTrack(int64(910+i), newUser(8, "dj"), "policy", "Policy")
+		track.Policy = test.policy
+		s.AddTrack(track, soundcloudtest.Audio{Snipped: test.snipped, Data: audioData(100, 1)})
+	}
+
+	sc := newTestAPI(t, s, nil)
+	// The policy must survive the round trip through api-v2
+	for i, test := range tests {
+		tracks, err := sc.GetTrackInfo(soundcloudapi.GetTrackInfoOptions{ID: []int64{int64(910 + i)}})
+		if err != nil || len(tracks) != 1 {
+			t.Errorf("Failed to get track %d: %v", 910+i, err)
+			continue
+		}
+		track := tracks[0]
+		if track.Policy != test.policy {
+			t.Errorf("Expected policy (%s), received (%s)", test.policy, track.Policy)
+		}
+		if track.IsPlayable() != test.playable || track.IsBlocked() != test.blocked || track.IsSnipped() != test.isSnip {
+			t.Errorf("Policy (%s): expected playable, blocked, snipped (%t, %t, %t), received (%t, %t, %t)", test.policy,
+				test.playable, test.blocked, test.isSnip, track.IsPlayable(), track.IsBlocked(), track.IsSnipped())
+		}
+	}
+}
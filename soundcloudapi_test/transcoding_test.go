@@ -0,0 +1,111 @@
+package soundcloudapi_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+	"github.com/zackradisic/soundcloud-api/soundcloudtest"
+)
+
+func newTranscoding(preset string, protocol string, mimeType string, snipped bool) soundcloudapi.Transcoding {
+	return soundcloudapi.Transcoding{
+		URL:     "https://api-v2.soundcloud.com/media/soundcloud:tracks:1/" + preset + "/stream/" + protocol,
+		Preset:  preset,
+		Snipped: snipped,
+		Format:  soundcloudapi.TranscodingFormat{Protocol: protocol, MimeType: mimeType},
+	}
+}
+
+func TestSelectTranscoding(t *testing.T) {
+	track := newTrack(1, newUser(8, "dj"), "select", "Select")
+	track.Media.Transcodings = []soundcloudapi.Transcoding{
+		newTranscoding("mp3_0_0", "hls", "audio/mpeg", false),
+		newTranscoding("mp3_0_0", "progressive", "audio/mpeg", false),
+		newTranscoding("opus_0_0", "hls", `audio/ogg; codecs="opus"`, false),
+		newTranscoding("aac_160k", "hls", `audio/mp4; codecs="mp4a.40.2"`, false),
+		newTranscoding("aac_256k", "hls", `audio/mp4; codecs="mp4a.40.2"`, true),
+	}
+
+	tests := []struct {
+		name     string
+		policy   soundcloudapi.TranscodingPolicy
+		expected int
+	}{
+		{"default", soundcloudapi.DefaultTranscodingPolicy(), 3},
+		{"default with snipped", func() soundcloudapi.TranscodingPolicy {
+			p := soundcloudapi.DefaultTranscodingPolicy()
+			p.AllowSnipped = true
+			return p
+		}(), 4},
+		{"zero value", soundcloudapi.TranscodingPolicy{}, 0},
+		{"protocol", soundcloudapi.TranscodingPolicy{Protocols: []string{"progressive"}}, 1},
+		{"codec", soundcloudapi.TranscodingPolicy{MimeTypes: []string{"opus"}}, 2},
+		{"mime type", soundcloudapi.TranscodingPolicy{MimeTypes: []string{"audio/mp4", "audio/mpeg"}}, 3},
+		{"preset prefix", soundcloudapi.TranscodingPolicy{Presets: []string{"mp3"}, Protocols: []string{"progressive"}}, 1},
+		{"preset before protocol", soundcloudapi.TranscodingPolicy{Presets: []string{"opus"}, Protocols: []string{"progressive"}}, 2},
+	}
+
+	for _, test := range tests {
+		transcoding, err := soundcloudapi.SelectTranscoding(track, test.policy)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err.Error())
+			continue
+		}
+		if expected := track.Media.Transcodings[test.expected]; transcoding != expected {
+			t.Errorf("%s: expected %s (%s), received %s (%s)", test.name, expected.Preset, expected.Format.Protocol, transcoding.Preset, transcoding.Format.Protocol)
+		}
+	}
+}
+
+func TestSelectTranscodingNoMatch(t *testing.T) {
+	track := newTrack(1, newUser(8, "dj"), "select", "Select")
+	track.Media.Transcodings = []soundcloudapi.Transcoding{
+		newTranscoding("mp3_0_0", "hls", "audio/mpeg", false),
+		newTranscoding("aac_256k", "hls", `audio/mp4; codecs="mp4a.40.2"`, true),
+	}
+
+	_, err := soundcloudapi.SelectTranscoding(track, soundcloudapi.TranscodingPolicy{Presets: []string{"aac"}, ExcludeUnlisted: true})
+	noTranscodingErr, ok := errors.Cause(err).(*soundcloudapi.NoTranscodingError)
+	if !ok {
+		t.Errorf("Expected a NoTranscodingError, received (%v)", err)
+		return
+	}
+	if len(noTranscodingErr.Rejected) != 2 {
+		t.Errorf("Expected (2) rejected transcodings, received (%d)", len(noTranscodingErr.Rejected))
+		return
+	}
+	if reason := noTranscodingErr.Rejected[0].Reason; !strings.Contains(reason, "preset mp3_0_0") {
+		t.Errorf("Expected the mp3_0_0 preset to be rejected, received (%s)", reason)
+	}
+	if reason := noTranscodingErr.Rejected[1].Reason; !strings.Contains(reason, "snipped") {
+		t.Errorf("Expected the snipped transcoding to be rejected, received (%s)", reason)
+	}
+
+	track.Media.Transcodings = nil
+	if _, err := soundcloudapi.SelectTranscoding(track, soundcloudapi.TranscodingPolicy{}); err == nil {
+		t.Error("Expected an error for a track without transcodings")
+	}
+}
+
+func TestDownloadTrackBest(t *testing.T) {
+	s := soundcloudtest.NewServer()
+	track := s.AddTrack(newTrack(903, newUser(8, "dj"), "best", "Best"),
+		soundcloudtest.Audio{Preset: "mp3_0_0", Protocol: "progressive", Data: audioData(300, 1)},
+		soundcloudtest.Audio{Preset: "aac_160k", Protocol: "progressive", MimeType: "audio/mp4", Data: audioData(300, 2), Snipped: true},
+		soundcloudtest.Audio{Preset: "opus_0_0", Protocol: "progressive", MimeType: `audio/ogg; codecs="opus"`, Data: audioData(300, 3)},
+	)
+
+	sc := newTestAPI(t, s, nil)
+
+	buf := &bytes.Buffer{}
+	if err := sc.DownloadTrackBest(track, soundcloudapi.DefaultTranscodingPolicy(), buf); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if !bytes.Equal(buf.Bytes(), audioData(300, 3)) {
+		t.Error("Expected the opus transcoding to be downloaded")
+	}
+}
@@ -0,0 +1,320 @@
+package soundcloudtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+)
+
+// maxTrackIDs is the maximum amount of IDs accepted by /tracks?ids=
+const maxTrackIDs = 50
+
+// playlistFullTracks is the amount of tracks of a resolved playlist that contain full track info,
+// the remaining tracks only contain their ID
+const playlistFullTracks = 5
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "resolve":
+		s.serveResolve(w, r)
+	case len(parts) == 1 && parts[0] == "tracks":
+		s.serveTracks(w, r)
+	case len(parts) == 3 && parts[0] == "tracks" && parts[2] == "download":
+		s.serveDownload(w, r, parts[1])
+	case len(parts) == 2 && parts[0] == "users":
+		s.serveUser(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "users":
+		s.serveLikes(w, r, parts[1], parts[2])
+	case parts[0] == "search" && len(parts) <= 2:
+		kind := ""
+		if len(parts) == 2 {
+			kind = parts[1]
+		}
+		s.serveSearch(w, r, kind)
+	case parts[0] == "media" && len(parts) == 5:
+		s.serveMedia(w, r, parts[1], parts[2])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) serveResolve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	resource, ok := s.resources[normalizePermalink(r.URL.Query().Get("url"))]
+	if playlist, isPlaylist := resource.(soundcloudapi.Playlist); isPlaylist {
+		resource = s.resolvedPlaylist(playlist)
+	}
+	if track, isTrack := resource.(soundcloudapi.Track); isTrack {
+		resource = s.tracks[track.ID].track
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, resource)
+}
+
+// resolvedPlaylist returns the playlist with up to date track info for the first
+// playlistFullTracks tracks and only the ID for the rest, like api-v2 does.
+// s.mu must be held.
+func (s *Server) resolvedPlaylist(playlist soundcloudapi.Playlist) map[string]interface{} {
+	tracks := make([]interface{}, len(playlist.Tracks))
+	for i, track := range playlist.Tracks {
+		if i < playlistFullTracks {
+			tracks[i] = s.tracks[track.ID].track
+		} else {
+			tracks[i] = map[string]interface{}{
+				"id":   track.ID,
+				"kind": "track",
+			}
+		}
+	}
+
+	m := toMap(playlist)
+	m["tracks"] = tracks
+	return m
+}
+
+func (s *Server) serveTracks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	ids := []int64{}
+	for _, raw := range strings.Split(query.Get("ids"), ",") {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", raw))
+			return
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) > maxTrackIDs {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d ids are allowed", maxTrackIDs))
+		return
+	}
+
+	s.mu.Lock()
+	playlist, hasPlaylist := s.playlists[parseID(query.Get("playlistId"))]
+	secretToken := query.Get("playlistSecretToken")
+
+	// api-v2 doesn't return tracks in the requested order
+	sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+
+	tracks := []soundcloudapi.Track{}
+	for _, id := range ids {
+		fixture, ok := s.tracks[id]
+		if !ok {
+			continue
+		}
+		if fixture.track.SecretToken != "" {
+			// Private tracks are only returned if they are accessed through a playlist that contains them
+			if !hasPlaylist || playlist.SecretToken != secretToken || !playlistContains(playlist, id) {
+				continue
+			}
+		}
+		tracks = append(tracks, fixture.track)
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, tracks)
+}
+
+func (s *Server) serveDownload(w http.ResponseWriter, r *http.Request, rawID string) {
+	s.mu.Lock()
+	fixture, ok := s.tracks[parseID(rawID)]
+	var signed string
+	if ok && fixture.original != nil && fixture.track.Downloadable && fixture.track.HasDownloadsLeft {
+		signed = s.signedCDNURL(fmt.Sprintf("%s/%d/original", cdnPath, fixture.track.ID))
+	}
+	s.mu.Unlock()
+
+	if signed == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, soundcloudapi.DownloadURLResponse{URL: signed})
+}
+
+func (s *Server) serveUser(w http.ResponseWriter, r *http.Request, rawID string) {
+	s.mu.Lock()
+	user, ok := s.users[parseID(rawID)]
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, user)
+}
+
+func (s *Server) serveLikes(w http.ResponseWriter, r *http.Request, rawID string, likeType string) {
+	id := parseID(rawID)
+
+	s.mu.Lock()
+	_, ok := s.users[id]
+	items := []interface{}{}
+	for _, like := range s.likes[id] {
+		item := map[string]interface{}{
+			"created_at": like.CreatedAt,
+			"kind":       like.Kind,
+		}
+		if like.Track.ID != 0 {
+			if likeType == "playlist_likes" {
+				continue
+			}
+			item["track"] = like.Track
+		} else {
+			if likeType == "track_likes" {
+				continue
+			}
+			item["playlist"] = like.Playlist
+		}
+		items = append(items, item)
+	}
+	s.mu.Unlock()
+
+	if !ok || (likeType != "likes" && likeType != "track_likes" && likeType != "playlist_likes") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	writeJSON(w, s.paginate(r, items, url.Values{}))
+}
+
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request, kind string) {
+	q := strings.ToLower(r.URL.Query().Get("q"))
+	matches := func(fields ...string) bool {
+		for _, field := range fields {
+			if strings.Contains(strings.ToLower(field), q) {
+				return true
+			}
+		}
+		return false
+	}
+
+	s.mu.Lock()
+	items := []interface{}{}
+	if kind == "" || kind == "tracks" {
+		ids := make([]int64, 0, len(s.tracks))
+		for id := range s.tracks {
+			ids = append(ids, id)
+		}
+		for _, id := range sortIDs(ids) {
+			track := s.tracks[id].track
+			if matches(track.Title, track.User.Username, track.TagList) {
+				items = append(items, track)
+			}
+		}
+	}
+	if kind == "" || kind == "users" {
+		ids := make([]int64, 0, len(s.users))
+		for id := range s.users {
+			ids = append(ids, id)
+		}
+		for _, id := range sortIDs(ids) {
+			if user := s.users[id]; matches(user.Username, user.FirstName, user.LastName) {
+				items = append(items, user)
+			}
+		}
+	}
+	if kind == "" || kind == "albums" || kind == "playlists_without_albums" {
+		ids := make([]int64, 0, len(s.playlists))
+		for id := range s.playlists {
+			ids = append(ids, id)
+		}
+		for _, id := range sortIDs(ids) {
+			playlist := s.playlists[id]
+			if (kind == "albums" && !playlist.IsAlbum) || (kind == "playlists_without_albums" && playlist.IsAlbum) {
+				continue
+			}
+			if matches(playlist.Title, playlist.User.Username, playlist.TagList) {
+				items = append(items, playlist)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if kind != "" && kind != "tracks" && kind != "users" && kind != "albums" && kind != "playlists_without_albums" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	writeJSON(w, s.paginate(r, items, url.Values{"q": {r.URL.Query().Get("q")}}))
+}
+
+// paginate returns the page of items selected by the limit and offset query parameters of r.
+// next_href doesn't contain the client_id, like api-v2.
+func (s *Server) paginate(r *http.Request, items []interface{}, nextQuery url.Values) soundcloudapi.PaginatedQuery {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	start := offset
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	query := soundcloudapi.PaginatedQuery{
+		Collection:   make([]map[string]interface{}, 0, end-start),
+		TotalResults: len(items),
+	}
+	for _, item := range items[start:end] {
+		query.Collection = append(query.Collection, toMap(item))
+	}
+
+	if end < len(items) {
+		nextQuery.Set("offset", strconv.Itoa(end))
+		nextQuery.Set("limit", strconv.Itoa(limit))
+		query.NextHref = s.URL + r.URL.Path + "?" + nextQuery.Encode()
+	}
+
+	return query
+}
+
+func sortIDs(ids []int64) []int64 {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func playlistContains(playlist soundcloudapi.Playlist, id int64) bool {
+	for _, track := range playlist.Tracks {
+		if track.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	data, err := json.Marshal(v)
+	if err == nil {
+		json.Unmarshal(data, &m)
+	}
+	return m
+}
+
+func parseID(raw string) int64 {
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return id
+}
@@ -0,0 +1,144 @@
+package soundcloudtest
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// FragmentedMP4 returns an fMP4 initialization segment for a single AAC track and one media segment
+// per fragment, each containing the given samples. Every sample lasts sampleDuration in timescale units.
+func FragmentedMP4(timescale uint32, sampleDuration uint32, fragments [][][]byte) (init []byte, segments [][]byte) {
+	fullBox := func(version byte, flags uint32, fields ...[]byte) []byte {
+		data := []byte{version, byte(flags >> 16), byte(flags >> 8), byte(flags)}
+		for _, field := range fields {
+			data = append(data, field...)
+		}
+		return data
+	}
+
+	ftyp := mp4Box("ftyp", []byte("iso6"), u32(0), []byte("iso6mp41"))
+	mvhd := mp4Box("mvhd", fullBox(0, 0, u32(0), u32(0), u32(1000), u32(0), u32(0x00010000), []byte{1, 0}, make([]byte, 10), identityMatrix(), make([]byte, 24), u32(2)))
+	tkhd := mp4Box("tkhd", fullBox(0, 3, u32(0), u32(0), u32(1), u32(0), u32(0), make([]byte, 8), make([]byte, 4), []byte{1, 0}, make([]byte, 2), identityMatrix(), u32(0), u32(0)))
+	mdhd := mp4Box("mdhd", fullBox(0, 0, u32(0), u32(0), u32(timescale), u32(0), []byte{0x55, 0xc4}, make([]byte, 2)))
+	hdlr := mp4Box("hdlr", fullBox(0, 0, u32(0), []byte("soun"), make([]byte, 12), []byte("SoundHandler\x00")))
+	smhd := mp4Box("smhd", fullBox(0, 0, make([]byte, 4)))
+	dinf := mp4Box("dinf", mp4Box("dref", fullBox(0, 0, u32(1), mp4Box("url ", fullBox(0, 1)))))
+	mp4a := mp4Box("mp4a", make([]byte, 6), []byte{0, 1}, make([]byte, 8), []byte{0, 2, 0, 16}, make([]byte, 4), u32(timescale<<16))
+	stbl := mp4Box("stbl",
+		mp4Box("stsd", fullBox(0, 0, u32(1), mp4a)),
+		mp4Box("stts", fullBox(0, 0, u32(0))),
+		mp4Box("stsc", fullBox(0, 0, u32(0))),
+		mp4Box("stsz", fullBox(0, 0, u32(0), u32(0))),
+		mp4Box("stco", fullBox(0, 0, u32(0))),
+	)
+	trak := mp4Box("trak", tkhd, mp4Box("mdia", mdhd, hdlr, mp4Box("minf", smhd, dinf, stbl)))
+	mvex := mp4Box("mvex", mp4Box("trex", fullBox(0, 0, u32(1), u32(1), u32(sampleDuration), u32(0), u32(0))))
+	init = append(ftyp, mp4Box("moov", mvhd, trak, mvex)...)
+
+	decodeTime := uint64(0)
+	for i, samples := range fragments {
+		moof := func(dataOffset uint32) []byte {
+			sizes := []byte{}
+			for _, sample := range samples {
+				sizes = append(sizes, u32(uint32(len(sample)))...)
+			}
+			tfdt := make([]byte, 8)
+			binary.BigEndian.PutUint64(tfdt, decodeTime)
+
+			// tfhd: default-base-is-moof and default-sample-duration, trun: data-offset and sample-size
+			return mp4Box("moof",
+				mp4Box("mfhd", fullBox(0, 0, u32(uint32(i+1)))),
+				mp4Box("traf",
+					mp4Box("tfhd", fullBox(0, 0x020008, u32(1), u32(sampleDuration))),
+					mp4Box("tfdt", fullBox(1, 0, tfdt)),
+					mp4Box("trun", fullBox(0, 0x000201, u32(uint32(len(samples))), u32(dataOffset), sizes)),
+				),
+			)
+		}
+
+		// The samples follow the moof box and the mdat header
+		segment := moof(uint32(len(moof(0)) + 8))
+		segment = append(segment, mp4Box("mdat", bytes.Join(samples, nil))...)
+		segments = append(segments, segment)
+		decodeTime += uint64(len(samples)) * uint64(sampleDuration)
+	}
+
+	return init, segments
+}
+
+func mp4Box(typ string, payload ...[]byte) []byte {
+	data := bytes.Join(payload, nil)
+	return append(append(u32(uint32(len(data)+8)), typ...), data...)
+}
+
+func identityMatrix() []byte {
+	return bytes.Join([][]byte{u32(0x00010000), u32(0), u32(0), u32(0), u32(0x00010000), u32(0), u32(0), u32(0), u32(0x40000000)}, nil)
+}
+
+func u32(v uint32) []byte {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, v)
+	return data
+}
+
+// OggOpus returns a complete Ogg Opus stream with the given serial number, in which each packet
+// is on its own page and decodes to samplesPerPacket samples
+func OggOpus(serial uint32, packets [][]byte, samplesPerPacket int) []byte {
+	head := append([]byte("OpusHead\x01\x02"), 0x38, 0x01) // version 1, 2 channels, 312 samples of pre-skip
+	head = append(head, 0x80, 0xbb, 0, 0, 0, 0, 0)         // 48kHz, no gain, mapping family 0
+	tags := []byte("OpusTags\x0d\x00\x00\x00soundcloudapi\x00\x00\x00\x00")
+
+	stream := &bytes.Buffer{}
+	sequence := uint32(0)
+	writePage := func(headerType byte, granule int64, packet []byte) {
+		lacing := []byte{}
+		for n := len(packet); ; n -= 255 {
+			if n < 255 {
+				lacing = append(lacing, byte(n))
+				break
+			}
+			lacing = append(lacing, 255)
+		}
+
+		page := make([]byte, 27)
+		copy(page, "OggS")
+		page[5] = headerType
+		binary.LittleEndian.PutUint64(page[6:14], uint64(granule))
+		binary.LittleEndian.PutUint32(page[14:18], serial)
+		binary.LittleEndian.PutUint32(page[18:22], sequence)
+		page[26] = byte(len(lacing))
+		page = append(append(page, lacing...), packet...)
+		binary.LittleEndian.PutUint32(page[22:26], OggCRC(page))
+		stream.Write(page)
+		sequence++
+	}
+
+	writePage(0x02, 0, head)
+	writePage(0, 0, tags)
+	for i, packet := range packets {
+		headerType := byte(0)
+		if i == len(packets)-1 {
+			headerType = 0x04
+		}
+		writePage(headerType, int64((i+1)*samplesPerPacket), packet)
+	}
+
+	return stream.Bytes()
+}
+
+// OggCRC computes the checksum of an Ogg page whose checksum field is 0
+func OggCRC(data []byte) uint32 {
+	crc := uint32(0)
+	for _, b := range data {
+		r := (crc>>24 ^ uint32(b)) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		crc = crc<<8 ^ r
+	}
+	return crc
+}
@@ -0,0 +1,274 @@
+package soundcloudtest
+
+import (
+	"fmt"
+	"strings"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+)
+
+// Audio is the media served for one of a track's transcodings
+type Audio struct {
+	Preset   string // e.g. "mp3_0_1", defaults to "mp3_0_0"
+	Protocol string // "progressive" or "hls", defaults to "progressive"
+	MimeType string // defaults to "audio/mpeg"
+	Snipped  bool
+	Data     []byte
+
+	// Segments are the HLS segments of Data. If nil, Data is split into segments of SegmentSize bytes.
+	Segments    [][]byte
+	SegmentSize int // defaults to 1024
+
+	// SegmentDuration is the EXTINF duration in seconds of each HLS segment, defaults to 10
+	SegmentDuration float64
+
+	// Inits are fMP4 initialization sections, each announced with an EXT-X-MAP tag before
+	// the segment at its index. They're only encrypted with Keys if IV is set.
+	Inits map[int][]byte
+
+	// ByteRanges makes the playlist reference the segments as byte ranges of a single file
+	ByteRanges bool
+
+	// RelativeURIs makes the playlist reference the segments with URIs relative to the playlist's URL
+	RelativeURIs bool
+
+	// Keys are AES-128 keys, each announced with an EXT-X-KEY tag before the segment at its index
+	// and encrypting the segments from there until the next key. Byte ranges are never encrypted.
+	Keys map[int][]byte
+
+	// IV is the IV attribute of the EXT-X-KEY tags. If nil, the IV of a segment is its media sequence number.
+	IV []byte
+
+	// EncryptionMethod is the METHOD attribute of the EXT-X-KEY tags, defaults to "AES-128".
+	// Segments are only encrypted with AES-128.
+	EncryptionMethod string
+
+	// MediaSequence is the media sequence number of the first segment
+	MediaSequence int
+
+	// Variants make the HLS playlist a master playlist of these variants instead of a media playlist
+	Variants []Variant
+}
+
+// Variant is a variant stream of an HLS master playlist
+type Variant struct {
+	Bandwidth int
+	Codecs    string
+	Data      []byte // split into segments of the audio's SegmentSize
+
+	segments [][]byte
+}
+
+type trackFixture struct {
+	track    soundcloudapi.Track
+	audio    []Audio
+	original []byte
+}
+
+// AddTrack adds a track to the store, replacing any track with the same ID.
+// A transcoding is added to track.Media for each of the given audio, the track's user
+// is added to the store if it isn't in it already, and the track can be resolved from its PermalinkURL.
+// The stored track is returned.
+func (s *Server) AddTrack(track soundcloudapi.Track, audio ...Audio) soundcloudapi.Track {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if track.Kind == "" {
+		track.Kind = "track"
+	}
+	if track.User.ID != 0 {
+		track.UserID = track.User.ID
+		if _, ok := s.users[track.User.ID]; !ok {
+			s.addUser(track.User)
+		}
+	}
+
+	track.Media.Transcodings = make([]soundcloudapi.Transcoding, len(audio))
+	for i := range audio {
+		a := &audio[i]
+		if a.Preset == "" {
+			a.Preset = "mp3_0_0"
+		}
+		if a.Protocol == "" {
+			a.Protocol = "progressive"
+		}
+		if a.MimeType == "" {
+			a.MimeType = "audio/mpeg"
+		}
+		if a.SegmentDuration == 0 {
+			a.SegmentDuration = 10
+		}
+		if a.EncryptionMethod == "" {
+			a.EncryptionMethod = "AES-128"
+		}
+		if a.Protocol == "hls" && a.Segments == nil {
+			a.Segments = splitSegments(a.Data, a.SegmentSize)
+		}
+		for j := range a.Variants {
+			a.Variants[j].segments = splitSegments(a.Variants[j].Data, a.SegmentSize)
+		}
+		track.Media.Transcodings[i] = soundcloudapi.Transcoding{
+			URL:     fmt.Sprintf("%s/media/soundcloud:tracks:%d/%d/stream/%s", s.URL, track.ID, i, a.Protocol),
+			Preset:  a.Preset,
+			Snipped: a.Snipped,
+			Format: soundcloudapi.TranscodingFormat{
+				Protocol: a.Protocol,
+				MimeType: a.MimeType,
+			},
+		}
+	}
+
+	fixture := &trackFixture{track: track, audio: audio}
+	if old, ok := s.tracks[track.ID]; ok {
+		fixture.original = old.original
+	}
+	s.tracks[track.ID] = fixture
+	s.addPermalink(track.PermalinkURL, track)
+
+	return track
+}
+
+// SetOriginal sets the original file served by /tracks/{id}/download for a track in the store.
+// The track must be Downloadable and HasDownloadsLeft for the file to be served.
+func (s *Server) SetOriginal(trackID int64, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fixture, ok := s.tracks[trackID]; ok {
+		fixture.original = data
+	}
+}
+
+// Track returns the track with the given ID from the store
+func (s *Server) Track(id int64) (soundcloudapi.Track, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fixture, ok := s.tracks[id]
+	if !ok {
+		return soundcloudapi.Track{}, false
+	}
+	return fixture.track, true
+}
+
+// AddPlaylist adds a playlist to the store, replacing any playlist with the same ID.
+// Tracks of the playlist that aren't in the store are added without any audio, and
+// TrackCount is set to the amount of tracks. The stored playlist is returned.
+func (s *Server) AddPlaylist(playlist soundcloudapi.Playlist) soundcloudapi.Playlist {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if playlist.Kind == "" {
+		playlist.Kind = "playlist"
+	}
+	if playlist.User.ID != 0 {
+		playlist.UserID = playlist.User.ID
+		if _, ok := s.users[playlist.User.ID]; !ok {
+			s.addUser(playlist.User)
+		}
+	}
+
+	tracks := make([]soundcloudapi.Track, len(playlist.Tracks))
+	for i, track := range playlist.Tracks {
+		fixture, ok := s.tracks[track.ID]
+		if !ok {
+			if track.Kind == "" {
+				track.Kind = "track"
+			}
+			fixture = &trackFixture{track: track}
+			s.tracks[track.ID] = fixture
+			s.addPermalink(track.PermalinkURL, track)
+		}
+		tracks[i] = fixture.track
+	}
+	playlist.Tracks = tracks
+	playlist.TrackCount = len(tracks)
+
+	s.playlists[playlist.ID] = playlist
+	s.addPermalink(playlist.PermalinkURL, playlist)
+
+	return playlist
+}
+
+// AddUser adds a user to the store, replacing any user with the same ID
+func (s *Server) AddUser(user soundcloudapi.User) soundcloudapi.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addUser(user)
+}
+
+func (s *Server) addUser(user soundcloudapi.User) soundcloudapi.User {
+	if user.Kind == "" {
+		user.Kind = "user"
+	}
+	s.users[user.ID] = user
+	s.addPermalink(user.PermalinkURL, user)
+	return user
+}
+
+// AddLike appends a like to a user's likes. Likes are served in the order they were added,
+// so they should be added newest first. Only one of like.Track or like.Playlist should be set.
+func (s *Server) AddLike(userID int64, like soundcloudapi.Like) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if like.Kind == "" {
+		like.Kind = "like"
+	}
+	s.likes[userID] = append(s.likes[userID], like)
+}
+
+// PrependLike adds a like before a user's other likes, as liking a track does
+func (s *Server) PrependLike(userID int64, like soundcloudapi.Like) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if like.Kind == "" {
+		like.Kind = "like"
+	}
+	s.likes[userID] = append([]soundcloudapi.Like{like}, s.likes[userID]...)
+}
+
+// RemoveLike removes a user's like of the track or playlist with the given ID
+func (s *Server) RemoveLike(userID int64, id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	likes := []soundcloudapi.Like{}
+	for _, like := range s.likes[userID] {
+		if like.Track.ID == id || like.Playlist.ID == id {
+			continue
+		}
+		likes = append(likes, like)
+	}
+	s.likes[userID] = likes
+}
+
+// AddImage stores an image, like artwork or an avatar, and returns its URL
+func (s *Server) AddImage(name string, data []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.images[name] = data
+	return s.URL + imagesPath + name
+}
+
+func (s *Server) addPermalink(permalinkURL string, resource interface{}) {
+	if permalinkURL == "" {
+		return
+	}
+	s.resources[normalizePermalink(permalinkURL)] = resource
+}
+
+func normalizePermalink(u string) string {
+	if i := strings.IndexAny(u, "?#"); i != -1 {
+		u = u[:i]
+	}
+	return strings.TrimRight(u, "/")
+}
+
+func splitSegments(data []byte, size int) [][]byte {
+	if size <= 0 {
+		size = 1024
+	}
+	segments := [][]byte{}
+	for len(data) > size {
+		segments = append(segments, data[:size])
+		data = data[size:]
+	}
+	return append(segments, data)
+}
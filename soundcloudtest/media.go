@@ -0,0 +1,279 @@
+package soundcloudtest
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+)
+
+// cdnPath is the path prefix of the media files served by the fake CDN
+const cdnPath = "/cdn"
+
+// imagesPath is the path prefix of the images served by the fake image CDN
+const imagesPath = "/images/"
+
+// serveMedia answers a transcoding URL with a signed CDN URL, like api-v2 does
+func (s *Server) serveMedia(w http.ResponseWriter, r *http.Request, urn string, rawIndex string) {
+	id := parseID(strings.TrimPrefix(urn, "soundcloud:tracks:"))
+	index, err := strconv.Atoi(rawIndex)
+
+	s.mu.Lock()
+	fixture, ok := s.tracks[id]
+	var signed string
+	if ok && err == nil && index >= 0 && index < len(fixture.audio) {
+		file := "file"
+		if fixture.audio[index].Protocol == "hls" {
+			file = "playlist.m3u8"
+		}
+		signed = s.signedCDNURL(fmt.Sprintf("%s/%d/%d/%s", cdnPath, id, index, file))
+	}
+	s.mu.Unlock()
+
+	if signed == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, soundcloudapi.MediaURLResponse{URL: signed})
+}
+
+// signedCDNURL returns the URL of path on the fake CDN, signed so that it expires
+// after the media URL TTL or when ExpireMediaURLs is called. s.mu must be held.
+func (s *Server) signedCDNURL(path string) string {
+	q := url.Values{}
+	q.Set("generation", strconv.Itoa(s.mediaGeneration))
+	if s.mediaTTL > 0 {
+		q.Set("expires", strconv.FormatInt(s.now().Add(s.mediaTTL).Unix(), 10))
+	}
+	return s.URL + path + "?" + q.Encode()
+}
+
+// validSignature reports whether the signature of a CDN URL hasn't expired. s.mu must be held.
+func (s *Server) validSignature(q url.Values) bool {
+	if q.Get("generation") != strconv.Itoa(s.mediaGeneration) {
+		return false
+	}
+	if expires := q.Get("expires"); expires != "" {
+		unix, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || !s.now().Before(time.Unix(unix, 0)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) serveCDN(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, cdnPath), "/"), "/")
+
+	s.mu.Lock()
+	valid := s.validSignature(r.URL.Query())
+	fixture, ok := s.tracks[parseID(parts[0])]
+	var audio *Audio
+	if ok && len(parts) >= 3 {
+		if index, err := strconv.Atoi(parts[1]); err == nil && index >= 0 && index < len(fixture.audio) {
+			audio = &fixture.audio[index]
+		}
+	}
+	var original []byte
+	if ok {
+		original = fixture.original
+	}
+	s.mu.Unlock()
+
+	if !valid {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Error><Code>AccessDenied</Code><Message>Access denied</Message></Error>")
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "original" && original != nil:
+		serveFile(w, r, "original.wav", original)
+	case audio != nil && len(parts) == 3 && parts[2] == "file" && audio.Protocol == "progressive":
+		serveFile(w, r, "", audio.Data)
+	case audio != nil && audio.Protocol == "hls":
+		s.serveHLS(w, r, fmt.Sprintf("%s/%s/%s", cdnPath, parts[0], parts[1]), audio, parts[2:])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveHLS serves the HLS playlists and segments of audio, whose files are under base
+func (s *Server) serveHLS(w http.ResponseWriter, r *http.Request, base string, audio *Audio, parts []string) {
+	segments := audio.Segments
+	if len(audio.Variants) > 0 {
+		if len(parts) == 1 && parts[0] == "playlist.m3u8" {
+			s.serveMasterPlaylist(w, r, audio)
+			return
+		}
+
+		v := -1
+		if len(parts) > 2 && parts[0] == "variant" {
+			v, _ = strconv.Atoi(parts[1])
+		}
+		if v < 0 || v >= len(audio.Variants) {
+			http.NotFound(w, r)
+			return
+		}
+		segments = audio.Variants[v].segments
+		base = fmt.Sprintf("%s/variant/%d", base, v)
+		parts = parts[2:]
+	}
+
+	switch {
+	case len(parts) == 1 && parts[0] == "playlist.m3u8":
+		s.servePlaylist(w, r, base, audio, segments)
+	case len(parts) == 2 && parts[0] == "segment":
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n < 0 || n >= len(segments) {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", audio.MimeType)
+		w.Write(encryptSegment(audio, n, segments[n]))
+	case len(parts) == 1 && parts[0] == "segments":
+		serveFile(w, r, "", bytes.Join(segments, nil))
+	case len(parts) == 2 && parts[0] == "init":
+		n, err := strconv.Atoi(parts[1])
+		init, ok := audio.Inits[n]
+		if err != nil || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", audio.MimeType)
+		if audio.IV != nil {
+			init = encryptSegment(audio, n, init)
+		}
+		w.Write(init)
+	case len(parts) == 2 && parts[0] == "key":
+		n, err := strconv.Atoi(parts[1])
+		key, ok := audio.Keys[n]
+		if err != nil || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(key)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// encryptSegment encrypts the segment at index n of audio with the key announced last before it
+func encryptSegment(audio *Audio, n int, segment []byte) []byte {
+	var key []byte
+	for i := n; i >= 0 && key == nil; i-- {
+		key = audio.Keys[i]
+	}
+	if key == nil || audio.EncryptionMethod != "AES-128" {
+		return segment
+	}
+
+	iv := audio.IV
+	if iv == nil {
+		iv = make([]byte, aes.BlockSize)
+		binary.BigEndian.PutUint64(iv[8:], uint64(audio.MediaSequence+n))
+	}
+
+	padding := aes.BlockSize - len(segment)%aes.BlockSize
+	data := append(append([]byte{}, segment...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	return data
+}
+
+// serveFile serves data with support for Range and If-Range requests
+func serveFile(w http.ResponseWriter, r *http.Request, name string, data []byte) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sha1.Sum(data)))
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
+
+func (s *Server) servePlaylist(w http.ResponseWriter, r *http.Request, base string, audio *Audio, segments [][]byte) {
+	s.mu.Lock()
+	signature := s.signedCDNURL("")[len(s.URL):]
+	s.mu.Unlock()
+
+	// uri returns the URI of the media file at path under base
+	uri := func(path string) string {
+		if audio.RelativeURIs {
+			return path + signature
+		}
+		return s.URL + base + "/" + path + signature
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "#EXTM3U\n#EXT-X-VERSION:6\n#EXT-X-PLAYLIST-TYPE:VOD\n")
+	fmt.Fprintf(buf, "#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:%d\n", int(audio.SegmentDuration+0.999), audio.MediaSequence)
+	for i, segment := range segments {
+		if _, ok := audio.Keys[i]; ok {
+			fmt.Fprintf(buf, "#EXT-X-KEY:METHOD=%s,URI=\"%s\"", audio.EncryptionMethod, uri(fmt.Sprintf("key/%d", i)))
+			if audio.IV != nil {
+				fmt.Fprintf(buf, ",IV=0x%x", audio.IV)
+			}
+			fmt.Fprintf(buf, "\n")
+		}
+		if _, ok := audio.Inits[i]; ok {
+			fmt.Fprintf(buf, "#EXT-X-MAP:URI=\"%s\"\n", uri(fmt.Sprintf("init/%d", i)))
+		}
+		fmt.Fprintf(buf, "#EXTINF:%.3f,\n", audio.SegmentDuration)
+		if audio.ByteRanges {
+			// Only the first range has an offset, the others follow the previous one
+			if i == 0 {
+				fmt.Fprintf(buf, "#EXT-X-BYTERANGE:%d@0\n", len(segment))
+			} else {
+				fmt.Fprintf(buf, "#EXT-X-BYTERANGE:%d\n", len(segment))
+			}
+			fmt.Fprintf(buf, "%s\n", uri("segments"))
+		} else {
+			fmt.Fprintf(buf, "%s\n", uri(fmt.Sprintf("segment/%d", i)))
+		}
+	}
+	fmt.Fprintf(buf, "#EXT-X-ENDLIST\n")
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.Write(buf.Bytes())
+}
+
+func (s *Server) serveMasterPlaylist(w http.ResponseWriter, r *http.Request, audio *Audio) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "#EXTM3U\n#EXT-X-VERSION:6\n")
+	for v, variant := range audio.Variants {
+		fmt.Fprintf(buf, "#EXT-X-STREAM-INF:BANDWIDTH=%d", variant.Bandwidth)
+		if variant.Codecs != "" {
+			fmt.Fprintf(buf, ",CODECS=\"%s\"", variant.Codecs)
+		}
+		uri := fmt.Sprintf("variant/%d/playlist.m3u8", v)
+		if !audio.RelativeURIs {
+			uri = strings.TrimSuffix(r.URL.Path, "playlist.m3u8") + uri
+		}
+		fmt.Fprintf(buf, "\n%s?%s\n", uri, r.URL.RawQuery)
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.Write(buf.Bytes())
+}
+
+func (s *Server) serveImage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data, ok := s.images[strings.TrimPrefix(r.URL.Path, imagesPath)]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	serveFile(w, r, r.URL.Path, data)
+}
@@ -0,0 +1,295 @@
+// Package soundcloudtest provides an in-process fake of SoundCloud's api-v2, web app and media CDN
+// for testing code built on soundcloudapi without network access.
+//
+// A Server is backed by an in-memory store of soundcloudapi.Track, soundcloudapi.Playlist and
+// soundcloudapi.User fixtures:
+//
+//	server := soundcloudtest.NewServer()
+//	defer server.Close()
+//
+//	server.AddTrack(track, soundcloudtest.Audio{Protocol: "hls", MimeType: "audio/mpeg", Data: mp3})
+//
+//	sc, err := soundcloudapi.New(server.APIOptions())
+package soundcloudtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	soundcloudapi "github.com/zackradisic/soundcloud-api"
+)
+
+// DefaultClientID is the client ID accepted by a new Server
+const DefaultClientID = "soundcloudtestclientid0000000000"
+
+// Server is a fake SoundCloud API server listening on a system-chosen port on the local loopback interface
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	clientID  string
+	tracks    map[int64]*trackFixture
+	playlists map[int64]soundcloudapi.Playlist
+	users     map[int64]soundcloudapi.User
+	resources map[string]interface{} // permalink URL -> Track, Playlist or User
+	likes     map[int64][]soundcloudapi.Like
+	images    map[string][]byte
+	faults    []*Fault
+	requests  []string
+
+	mediaTTL        time.Duration
+	mediaGeneration int
+	now             func() time.Time
+}
+
+// Fault makes the server answer matching requests with an error response instead of handling them
+type Fault struct {
+	PathPrefix string        // requests whose path starts with this prefix are failed, "" matches every request
+	Status     int           // status code of the response
+	Body       string        // optional body of the response
+	RetryAfter time.Duration // sets the Retry-After header if greater than 0
+	Times      int           // how many requests to fail, 0 means every matching request
+
+	// DropAfter makes the server handle the request normally but close the connection after
+	// writing DropAfter bytes of the response body, if greater than 0. Status and Body are ignored.
+	DropAfter int64
+}
+
+// NewServer starts and returns a new Server with an empty fixture store.
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		clientID:  DefaultClientID,
+		tracks:    map[int64]*trackFixture{},
+		playlists: map[int64]soundcloudapi.Playlist{},
+		users:     map[int64]soundcloudapi.User{},
+		resources: map[string]interface{}{},
+		likes:     map[int64][]soundcloudapi.Like{},
+		images:    map[string][]byte{},
+		now:       time.Now,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// APIBaseURL returns the base URL of the fake api-v2
+func (s *Server) APIBaseURL() string {
+	return s.URL
+}
+
+// WebBaseURL returns the base URL of the fake web app that the client ID can be scraped from
+func (s *Server) WebBaseURL() string {
+	return s.URL + "/web"
+}
+
+// AssetBaseURL returns the base URL of the fake web app's JS assets
+func (s *Server) AssetBaseURL() string {
+	return s.URL + "/assets/"
+}
+
+// APIOptions returns soundcloudapi.APIOptions that point an API at the server
+func (s *Server) APIOptions() soundcloudapi.APIOptions {
+	return soundcloudapi.APIOptions{
+		ClientID:     s.ClientID(),
+		HTTPClient:   s.Client(),
+		APIBaseURL:   s.APIBaseURL(),
+		WebBaseURL:   s.WebBaseURL(),
+		AssetBaseURL: s.AssetBaseURL(),
+	}
+}
+
+// ClientIDProvider returns a soundcloudapi.ScrapingClientIDProvider that scrapes the server's web app
+func (s *Server) ClientIDProvider() *soundcloudapi.ScrapingClientIDProvider {
+	return &soundcloudapi.ScrapingClientIDProvider{
+		HTTPClient:   s.Client(),
+		WebBaseURL:   s.WebBaseURL(),
+		AssetBaseURL: s.AssetBaseURL(),
+		APIBaseURL:   s.APIBaseURL(),
+	}
+}
+
+// ClientID returns the only client ID the server currently accepts
+func (s *Server) ClientID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clientID
+}
+
+// RotateClientID replaces the accepted client ID, API requests made with the previous one
+// will fail with 401 Unauthorized. The web app serves the new client ID.
+func (s *Server) RotateClientID(clientID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientID = clientID
+}
+
+// InjectFault registers a fault. Faults are matched in the order they were injected.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every injected fault
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// SetMediaURLTTL makes media URLs handed out by the server expire after ttl, 0 disables expiry
+func (s *Server) SetMediaURLTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mediaTTL = ttl
+}
+
+// ExpireMediaURLs expires every media URL handed out so far. Requests to them will fail with 403 Forbidden.
+func (s *Server) ExpireMediaURLs() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mediaGeneration++
+}
+
+// RequestCount returns the number of requests received whose path starts with pathPrefix
+func (s *Server) RequestCount(pathPrefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, path := range s.requests {
+		if strings.HasPrefix(path, pathPrefix) {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if f := s.matchFault(r); f != nil {
+		if f.DropAfter > 0 {
+			w = &droppingResponseWriter{ResponseWriter: w, remaining: f.DropAfter}
+		} else {
+			if f.RetryAfter > 0 {
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(f.RetryAfter.Seconds()+0.5)))
+			}
+			w.WriteHeader(f.Status)
+			fmt.Fprint(w, f.Body)
+			return
+		}
+	}
+
+	path := r.URL.Path
+	switch {
+	case path == "/web" || strings.HasPrefix(path, "/web/"):
+		s.serveWebApp(w, r)
+	case strings.HasPrefix(path, "/assets/"):
+		s.serveAsset(w, r)
+	case strings.HasPrefix(path, cdnPath):
+		s.serveCDN(w, r)
+	case strings.HasPrefix(path, imagesPath):
+		s.serveImage(w, r)
+	default:
+		if r.URL.Query().Get("client_id") != s.ClientID() {
+			writeError(w, http.StatusUnauthorized, "invalid client_id")
+			return
+		}
+		s.serveAPI(w, r)
+	}
+}
+
+func (s *Server) matchFault(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.URL.Path)
+
+	for i, f := range s.faults {
+		if !strings.HasPrefix(r.URL.Path, f.PathPrefix) {
+			continue
+		}
+		matched := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return &matched
+	}
+
+	return nil
+}
+
+func (s *Server) serveWebApp(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<title>SoundCloud</title>\n</head>\n<body>\n")
+	fmt.Fprintf(w, "<script crossorigin src=\"%svendor.js\"></script>\n", s.AssetBaseURL())
+	fmt.Fprintf(w, "<script crossorigin src=\"%sapp.js\"></script>\n", s.AssetBaseURL())
+	fmt.Fprintf(w, "</body>\n</html>\n")
+}
+
+func (s *Server) serveAsset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	switch r.URL.Path {
+	case "/assets/vendor.js":
+		fmt.Fprint(w, `(function(){var e={};return e})();`)
+	case "/assets/app.js":
+		fmt.Fprintf(w, `window.__sc_hydration=[];var config={env:"production",client_id:"%s",api_host:"%s"};`, s.ClientID(), s.URL)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// droppingResponseWriter closes the connection after remaining bytes of the body were written
+type droppingResponseWriter struct {
+	http.ResponseWriter
+	remaining int64
+	dropped   bool
+}
+
+func (w *droppingResponseWriter) Write(p []byte) (int, error) {
+	if w.dropped {
+		return 0, errors.New("connection dropped")
+	}
+	if int64(len(p)) <= w.remaining {
+		w.remaining -= int64(len(p))
+		return w.ResponseWriter.Write(p)
+	}
+
+	n, _ := w.ResponseWriter.Write(p[:w.remaining])
+	w.dropped = true
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		if conn, _, err := hijacker.Hijack(); err == nil {
+			conn.Close()
+		}
+	}
+	return n, errors.New("connection dropped")
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	data, _ := json.Marshal(map[string]interface{}{
+		"code":    status,
+		"message": msg,
+	})
+	w.Write(data)
+}
@@ -0,0 +1,262 @@
+package soundcloudapi
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultSyncTemplate is the default path template of the tracks downloaded by SyncLikes
+const DefaultSyncTemplate = `{{.User.Username}} - {{.Title}}.{{.Ext}}`
+
+// DefaultSyncStateFile is the default name of the state file SyncLikes keeps in the directory
+const DefaultSyncStateFile = ".soundcloud-sync.json"
+
+// DefaultSyncTrashDir is the default name of the directory SyncLikes moves unliked tracks to
+const DefaultSyncTrashDir = ".trash"
+
+// SyncOptions are the options of SyncLikes
+type SyncOptions struct {
+	Workers  int               // number of tracks downloaded concurrently, defaults to DefaultPlaylistWorkers
+	Template string            // FilenameTemplate of each track's path relative to the directory, defaults to DefaultSyncTemplate
+	Policy   TranscodingPolicy // picks the transcoding of each track
+	Archive  *Archive          // if set, tracks it has as downloaded or failed too many times are skipped, and it records the downloads
+	Tag      bool              // whether or not to write an ID3v2 tag to audio/mpeg tracks, like DownloadOptions.Tag
+
+	// Progress is called with the progress of each track's download, like PlaylistDownloadOptions.Progress
+	Progress func(result PlaylistTrackResult, progress Progress)
+
+	StateFile string // path of the state file, defaults to DefaultSyncStateFile in the directory
+
+	// TrashUnliked moves the files of the tracks that aren't liked anymore to TrashDir. Every like is
+	// walked to find them, instead of only the ones since the last sync.
+	TrashUnliked bool
+	TrashDir     string // defaults to DefaultSyncTrashDir in the directory
+}
+
+// SyncedTrack is a track downloaded by SyncLikes
+type SyncedTrack struct {
+	ID      int64     `json:"id"`
+	Title   string    `json:"title"`
+	Path    string    `json:"path"` // relative to the directory
+	LikedAt time.Time `json:"liked_at"`
+}
+
+// SyncSummary is what SyncLikes did
+type SyncSummary struct {
+	Added   []SyncedTrack
+	Removed []SyncedTrack         // moved to the trash
+	Failed  []PlaylistTrackResult // retried by the next sync, unless the error is permanent, like ErrBlocked
+	Skipped []PlaylistTrackResult // skipped because of the Archive
+}
+
+// syncState is the content of the state file
+type syncState struct {
+	// Cursor is the time of the newest like such that it and every older like were synced
+	Cursor time.Time             `json:"cursor"`
+	Tracks map[int64]SyncedTrack `json:"tracks"`
+}
+
+// SyncLikes downloads the tracks a user liked since the last sync to dir. The user and the page
+// size are taken from likes. Likes are walked newest first until the cursor kept in the state file,
+// which only moves past a like once it was synced, so a sync that failed or was interrupted is
+// picked up by the next one. Failed tracks are retried by the next sync, see Archive to limit how often.
+func (sc *API) SyncLikes(likes GetLikesOptions, dir string, options SyncOptions) (SyncSummary, error) {
+	return sc.SyncLikesContext(context.Background(), likes, dir, options)
+}
+
+// SyncLikesContext is like SyncLikes but with a context
+func (sc *API) SyncLikesContext(ctx context.Context, likes GetLikesOptions, dir string, options SyncOptions) (SyncSummary, error) {
+	summary := SyncSummary{}
+	if options.Template == "" {
+		options.Template = DefaultSyncTemplate
+	}
+	if options.StateFile == "" {
+		options.StateFile = filepath.Join(dir, DefaultSyncStateFile)
+	}
+	if options.TrashDir == "" {
+		options.TrashDir = filepath.Join(dir, DefaultSyncTrashDir)
+	}
+
+	state, err := readSyncState(options.StateFile)
+	if err != nil {
+		return summary, err
+	}
+
+	// Walk the likes newest first. newLikes are the likes since the cursor.
+	likes.Type = "track"
+	likes.Offset = ""
+	pager := sc.LikesPager(likes)
+	newLikes := []Like{}
+	liked := map[int64]bool{}
+	walking := true
+	for walking && pager.More() {
+		page, err := pager.Next(ctx)
+		if err == ErrNoMorePages {
+			break
+		}
+		if err != nil {
+			return summary, err
+		}
+		pageLikes, err := page.GetLikes()
+		if err != nil {
+			return summary, err
+		}
+
+		for _, like := range pageLikes {
+			if like.Track.ID == 0 {
+				continue
+			}
+			createdAt, err := time.Parse(time.RFC3339, like.CreatedAt)
+			if err != nil {
+				return summary, errors.Wrap(err, "Failed to parse the date of a like")
+			}
+
+			liked[like.Track.ID] = true
+			if !createdAt.After(state.Cursor) {
+				if !options.TrashUnliked {
+					walking = false
+					break
+				}
+				continue
+			}
+			newLikes = append(newLikes, like)
+		}
+	}
+
+	if options.TrashUnliked {
+		for id, track := range state.Tracks {
+			if liked[id] {
+				continue
+			}
+			if err := trashFile(filepath.Join(dir, track.Path), filepath.Join(options.TrashDir, track.Path)); err != nil {
+				return summary, err
+			}
+			delete(state.Tracks, id)
+			summary.Removed = append(summary.Removed, track)
+		}
+		if err := writeSyncState(options.StateFile, state); err != nil {
+			return summary, err
+		}
+	}
+
+	// The tracks synced by an interrupted sync are already done
+	tracks := []Track{}
+	likedAt := map[int64]time.Time{}
+	for _, like := range newLikes {
+		if _, ok := state.Tracks[like.Track.ID]; !ok {
+			tracks = append(tracks, like.Track)
+			likedAt[like.Track.ID], _ = time.Parse(time.RFC3339, like.CreatedAt)
+		}
+	}
+	taken := []string{}
+	for _, track := range state.Tracks {
+		taken = append(taken, track.Path)
+	}
+
+	failed := map[int64]bool{}
+	var saveErr error
+	_, err = sc.downloadTracks(ctx, Playlist{Title: "Likes", Tracks: tracks}, dir, PlaylistDownloadOptions{
+		Workers:  options.Workers,
+		Template: options.Template,
+		Policy:   options.Policy,
+		Archive:  options.Archive,
+		Tag:      options.Tag,
+		Progress: options.Progress,
+		OnResult: func(result PlaylistTrackResult) {
+			switch {
+			case result.Err != nil:
+				summary.Failed = append(summary.Failed, result)
+				if !permanentSyncError(result.Err) {
+					failed[result.Track.ID] = true
+				}
+			case result.Skipped:
+				summary.Skipped = append(summary.Skipped, result)
+			default:
+				// Saved after every track, so that a crash doesn't lose it
+				synced := SyncedTrack{ID: result.Track.ID, Title: result.Track.Title, Path: result.Path, LikedAt: likedAt[result.Track.ID]}
+				state.Tracks[synced.ID] = synced
+				summary.Added = append(summary.Added, synced)
+				if err := writeSyncState(options.StateFile, state); err != nil && saveErr == nil {
+					saveErr = err
+				}
+			}
+		},
+	}, taken)
+	if err != nil {
+		return summary, err
+	}
+	if saveErr != nil {
+		return summary, saveErr
+	}
+
+	// The cursor moves to the newest like such that it and every older like are synced,
+	// which are the likes older than the oldest one that failed
+	for i := len(newLikes) - 1; i >= 0; i-- {
+		if failed[newLikes[i].Track.ID] {
+			break
+		}
+		state.Cursor, _ = time.Parse(time.RFC3339, newLikes[i].CreatedAt)
+	}
+
+	return summary, writeSyncState(options.StateFile, state)
+}
+
+// permanentSyncError returns true if err will happen again however many times a track is synced
+func permanentSyncError(err error) bool {
+	switch errors.Cause(err) {
+	case ErrBlocked, ErrPreviewOnly, ErrTooManyFailures:
+		return true
+	}
+	_, ok := errors.Cause(err).(*NoTranscodingError)
+	return ok
+}
+
+func readSyncState(path string) (*syncState, error) {
+	state := &syncState{Tracks: map[int64]SyncedTrack{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read sync state")
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode sync state")
+	}
+	if state.Tracks == nil {
+		state.Tracks = map[int64]SyncedTrack{}
+	}
+	return state, nil
+}
+
+// writeSyncState replaces the state file with a rename, so that it's never left half written
+func writeSyncState(path string, state *syncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode sync state")
+	}
+
+	tmp := path + partSuffix
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Wrap(err, "Failed to write sync state")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "Failed to write sync state")
+}
+
+// trashFile moves the file at path to trash, if it still exists
+func trashFile(path string, trash string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(trash), 0755); err != nil {
+		return errors.Wrap(err, "Failed to create trash directory")
+	}
+	return errors.Wrap(os.Rename(path, trash), "Failed to move file to the trash")
+}
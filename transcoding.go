@@ -0,0 +1,168 @@
+package soundcloudapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// TranscodingPolicy ranks the transcodings of a track to pick the one to download.
+//
+// Transcodings are ranked by preset first, then by mime type, then by protocol, each in the order of its
+// list of preferences. Values in none of a list's preferences rank after the ones that are, and an
+// empty list doesn't rank by that attribute. Transcodings ranked equal keep the track's order.
+type TranscodingPolicy struct {
+	Presets   []string // presets or preset prefixes, e.g. "aac_256k" or "mp3" which matches "mp3_0_1"
+	MimeTypes []string // mime types, e.g. "audio/mp4", or codecs, e.g. "opus" which matches `audio/ogg; codecs="opus"`
+	Protocols []string // "progressive" or "hls"
+
+	ExcludeUnlisted bool // whether or not to exclude transcodings that match none of the preferences of a non-empty list
+	AllowSnipped    bool // whether or not to allow snipped transcodings, which are 30 second previews, instead of APIOptions.AllowPreviews
+}
+
+// DefaultTranscodingPolicy returns a TranscodingPolicy that prefers high quality AAC, then Opus, then MP3,
+// and progressive downloads over HLS
+func DefaultTranscodingPolicy() TranscodingPolicy {
+	return TranscodingPolicy{
+		Presets:   []string{"aac_256k", "aac_hq", "aac", "opus", "mp3"},
+		Protocols: []string{"progressive", "hls"},
+	}
+}
+
+// RejectedTranscoding is a transcoding a TranscodingPolicy excluded, and why
+type RejectedTranscoding struct {
+	Transcoding Transcoding
+	Reason      string
+}
+
+// NoTranscodingError is returned by SelectTranscoding when the policy excludes every transcoding of a track
+type NoTranscodingError struct {
+	TrackID  int64
+	Rejected []RejectedTranscoding // empty if the track has no transcodings
+}
+
+func (e *NoTranscodingError) Error() string {
+	if len(e.Rejected) == 0 {
+		return fmt.Sprintf("Track %d has no transcodings", e.TrackID)
+	}
+
+	reasons := make([]string, len(e.Rejected))
+	for i, rejected := range e.Rejected {
+		reasons[i] = fmt.Sprintf("%s (%s): %s", rejected.Transcoding.Preset, rejected.Transcoding.Format.Protocol, rejected.Reason)
+	}
+	return fmt.Sprintf("No transcoding of track %d matches the policy: %s", e.TrackID, strings.Join(reasons, "; "))
+}
+
+// SelectTranscoding returns the transcoding of track that policy ranks best.
+// A *NoTranscodingError is returned if policy excludes every transcoding.
+func SelectTranscoding(track Track, policy TranscodingPolicy) (Transcoding, error) {
+	rejected := []RejectedTranscoding{}
+	var best Transcoding
+	var bestRank [3]int
+	found := false
+	for _, transcoding := range track.Media.Transcodings {
+		rank, reason := policy.rank(transcoding)
+		if reason != "" {
+			rejected = append(rejected, RejectedTranscoding{Transcoding: transcoding, Reason: reason})
+			continue
+		}
+		if !found || rankLess(rank, bestRank) {
+			best, bestRank, found = transcoding, rank, true
+		}
+	}
+
+	if !found {
+		return Transcoding{}, &NoTranscodingError{TrackID: track.ID, Rejected: rejected}
+	}
+	return best, nil
+}
+
+// rank returns the rank of transcoding by preset, mime type and protocol, lower is better.
+// If the policy excludes transcoding, the reason is returned.
+func (p TranscodingPolicy) rank(transcoding Transcoding) ([3]int, string) {
+	if transcoding.Snipped && !p.AllowSnipped {
+		return [3]int{}, "snipped preview"
+	}
+
+	rank := [3]int{
+		preference(p.Presets, func(preset string) bool { return matchPreset(transcoding.Preset, preset) }),
+		preference(p.MimeTypes, func(mimeType string) bool { return matchMimeType(transcoding.Format.MimeType, mimeType) }),
+		preference(p.Protocols, func(protocol string) bool { return strings.EqualFold(transcoding.Format.Protocol, protocol) }),
+	}
+
+	if p.ExcludeUnlisted {
+		switch {
+		case len(p.Presets) > 0 && rank[0] == len(p.Presets):
+			return rank, fmt.Sprintf("preset %s not allowed", transcoding.Preset)
+		case len(p.MimeTypes) > 0 && rank[1] == len(p.MimeTypes):
+			return rank, fmt.Sprintf("mime type %s not allowed", transcoding.Format.MimeType)
+		case len(p.Protocols) > 0 && rank[2] == len(p.Protocols):
+			return rank, fmt.Sprintf("protocol %s not allowed", transcoding.Format.Protocol)
+		}
+	}
+
+	return rank, ""
+}
+
+// preference returns the index of the first of preferences that matches, or len(preferences) if none does
+func preference(preferences []string, match func(string) bool) int {
+	for i, preference := range preferences {
+		if match(preference) {
+			return i
+		}
+	}
+	return len(preferences)
+}
+
+func rankLess(a, b [3]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+func matchPreset(preset string, want string) bool {
+	preset, want = strings.ToLower(preset), strings.ToLower(want)
+	return preset == want || strings.HasPrefix(preset, want+"_")
+}
+
+// matchMimeType matches mimeType against a mime type without parameters, or else against a codec prefix
+func matchMimeType(mimeType string, want string) bool {
+	mediaType, params, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	want = strings.ToLower(want)
+	if strings.Contains(want, "/") {
+		return mediaType == want
+	}
+
+	for _, codec := range strings.Split(params["codecs"], ",") {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(codec)), want) {
+			return true
+		}
+	}
+	return false
+}
+
+// DownloadTrackBest downloads the transcoding of track that policy ranks best to dst.
+// Whether previews are downloaded is decided by policy.AllowSnipped, not APIOptions.AllowPreviews.
+func (sc *API) DownloadTrackBest(track Track, policy TranscodingPolicy, dst io.Writer) error {
+	return sc.DownloadTrackBestContext(context.Background(), track, policy, dst)
+}
+
+// DownloadTrackBestContext is like DownloadTrackBest but with a context
+func (sc *API) DownloadTrackBestContext(ctx context.Context, track Track, policy TranscodingPolicy, dst io.Writer) error {
+	transcoding, err := SelectTranscoding(track, policy)
+	if err != nil {
+		if previewOnly(track) {
+			return newPreviewOnlyError(track)
+		}
+		return err
+	}
+	return sc.downloadTrack(ctx, transcoding, dst, DownloadOptions{})
+}